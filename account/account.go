@@ -1,77 +1,413 @@
 package account
 
 import (
+	"context"
 	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/google/uuid"
 
 	"github.com/flarexio/core/model"
+	"github.com/flarexio/wallet/inspect"
 	"github.com/flarexio/wallet/keys"
 )
 
-func NewAccount(subject string, key keys.Key) (*Account, error) {
-	salt := uuid.New().String()
-	data := []byte(subject + salt)
+func NewAccount(ctx context.Context, subject string, key keys.Key) (*Account, error) {
+	a := &Account{
+		Subject:    subject,
+		Salt:       uuid.New().String(),
+		KeyVersion: key.Version(),
+		Wallets:    make([]*Wallet, 0),
+		Model: model.Model{
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+	}
 
-	seed, err := key.Signature(data)
-	if err != nil {
+	if _, err := a.NewWallet(ctx, key, DefaultWalletLabel); err != nil {
 		return nil, err
 	}
 
-	privkey := ed25519.NewKeyFromSeed(seed[:ed25519.SeedSize])
+	return a, nil
+}
 
-	return &Account{
+// NewImportedAccount creates an account whose default wallet holds an
+// existing keypair rather than one derived from the master key.
+func NewImportedAccount(subject string, key keys.Key, privkey ed25519.PrivateKey, label string) (*Account, error) {
+	if label == "" {
+		label = DefaultWalletLabel
+	}
+
+	a := &Account{
 		Subject:    subject,
-		Salt:       salt,
+		Salt:       uuid.New().String(),
 		KeyVersion: key.Version(),
-		PrivateKey: privkey,
+		Wallets:    make([]*Wallet, 0),
 		Model: model.Model{
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		},
-	}, nil
+	}
+
+	if _, err := a.ImportWallet(privkey, label); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// DeriveKey derives the private key of the wallet at index from the master
+// key. Ed25519 signatures are deterministic, so the same inputs always yield
+// the same key. Index 0 keeps the original derivation of single-wallet accounts.
+func DeriveKey(ctx context.Context, subject string, salt string, index int, key keys.Key) (ed25519.PrivateKey, error) {
+	data := []byte(subject + salt)
+	if index > 0 {
+		data = []byte(subject + salt + "/" + strconv.Itoa(index))
+	}
+
+	seed, err := key.Signature(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(seed) < ed25519.SeedSize {
+		return nil, errors.New("invalid signature")
+	}
+
+	return ed25519.NewKeyFromSeed(seed[:ed25519.SeedSize]), nil
+}
+
+const DefaultWalletLabel = "default"
+
+type Wallet struct {
+	Index        int
+	Label        string
+	PublicKey    solana.PublicKey
+	PrivateKey   ed25519.PrivateKey
+	EncryptedKey *EncryptedKey
+
+	// Imported wallets hold a keypair brought in by the user. They were
+	// not derived from the master key and cannot be recovered from it.
+	Imported bool
+
+	CreatedAt time.Time
 }
 
 type Account struct {
 	Subject    string
 	Salt       string
 	KeyVersion int
-	PrivateKey ed25519.PrivateKey
+	Wallets    []*Wallet
+	Frozen     *Freeze
 	model.Model
 }
 
+// Freeze locks an account out of signing, e.g. after a reported compromise
+// of the identity provider login.
+type Freeze struct {
+	Reason string
+	By     string
+	At     time.Time
+}
+
+func (a *Account) UnmarshalJSON(data []byte) error {
+	type alias Account
+
+	var raw struct {
+		alias
+
+		// single-wallet records persisted before Wallets was introduced
+		PublicKey    solana.PublicKey
+		PrivateKey   ed25519.PrivateKey
+		EncryptedKey *EncryptedKey
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*a = Account(raw.alias)
+
+	if len(a.Wallets) == 0 && (raw.EncryptedKey != nil || raw.PrivateKey != nil || !raw.PublicKey.IsZero()) {
+		w := &Wallet{
+			Index:        0,
+			Label:        DefaultWalletLabel,
+			PublicKey:    raw.PublicKey,
+			PrivateKey:   raw.PrivateKey,
+			EncryptedKey: raw.EncryptedKey,
+			CreatedAt:    a.CreatedAt,
+		}
+
+		if w.PublicKey.IsZero() && len(w.PrivateKey) == ed25519.PrivateKeySize {
+			w.PublicKey = solana.PublicKeyFromBytes(w.PrivateKey.Public().(ed25519.PublicKey))
+		}
+
+		a.Wallets = []*Wallet{w}
+	}
+
+	return nil
+}
+
+// Wallet returns the address of the default wallet.
 func (a *Account) Wallet() solana.PublicKey {
-	pub, ok := a.PrivateKey.Public().(ed25519.PublicKey)
-	if !ok {
-		panic("invalid private key")
+	w, err := a.FindWallet(0)
+	if err != nil {
+		return solana.PublicKey{}
+	}
+
+	return w.PublicKey
+}
+
+func (a *Account) FindWallet(index int) (*Wallet, error) {
+	for _, w := range a.Wallets {
+		if w.Index == index {
+			return w, nil
+		}
+	}
+
+	return nil, ErrWalletNotFound
+}
+
+// NewWallet derives the next indexed wallet from the master key.
+func (a *Account) NewWallet(ctx context.Context, key keys.Key, label string) (*Wallet, error) {
+	if key.Version() != a.KeyVersion {
+		return nil, errors.New("invalid key version")
+	}
+
+	if label == "" {
+		return nil, errors.New("label is required")
+	}
+
+	index := 0
+	for _, w := range a.Wallets {
+		if w.Label == label {
+			return nil, ErrWalletLabelExists
+		}
+
+		index = max(index, w.Index+1)
+	}
+
+	privkey, err := DeriveKey(ctx, a.Subject, a.Salt, index, key)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Wallet{
+		Index:      index,
+		Label:      label,
+		PublicKey:  solana.PublicKeyFromBytes(privkey.Public().(ed25519.PublicKey)),
+		PrivateKey: privkey,
+		CreatedAt:  time.Now(),
+	}
+
+	a.Wallets = append(a.Wallets, w)
+	a.UpdatedAt = time.Now()
+
+	return w, nil
+}
+
+func (a *Account) ImportWallet(privkey ed25519.PrivateKey, label string) (*Wallet, error) {
+	if len(privkey) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid private key")
+	}
+
+	// a keypair is seed || public key; reject halves that do not belong together
+	pubkey := ed25519.NewKeyFromSeed(privkey.Seed()).Public().(ed25519.PublicKey)
+	if !pubkey.Equal(privkey.Public()) {
+		return nil, errors.New("invalid private key")
+	}
+
+	if label == "" {
+		return nil, errors.New("label is required")
 	}
 
-	return solana.PublicKeyFromBytes(pub)
+	wallet := solana.PublicKeyFromBytes(pubkey)
+
+	index := 0
+	for _, w := range a.Wallets {
+		if w.Label == label {
+			return nil, ErrWalletLabelExists
+		}
+
+		if w.PublicKey.Equals(wallet) {
+			return nil, ErrWalletExists
+		}
+
+		index = max(index, w.Index+1)
+	}
+
+	w := &Wallet{
+		Index:      index,
+		Label:      label,
+		PublicKey:  wallet,
+		PrivateKey: privkey,
+		Imported:   true,
+		CreatedAt:  time.Now(),
+	}
+
+	a.Wallets = append(a.Wallets, w)
+	a.UpdatedAt = time.Now()
+
+	return w, nil
+}
+
+func (a *Account) RenameWallet(index int, label string) (*Wallet, error) {
+	if label == "" {
+		return nil, errors.New("label is required")
+	}
+
+	w, err := a.FindWallet(index)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, other := range a.Wallets {
+		if other != w && other.Label == label {
+			return nil, ErrWalletLabelExists
+		}
+	}
+
+	w.Label = label
+	a.UpdatedAt = time.Now()
+
+	return w, nil
+}
+
+func (a *Account) IsFrozen() bool {
+	return a.Frozen != nil
 }
 
-func (a *Account) Sign(data []byte) []byte {
-	return ed25519.Sign(a.PrivateKey, data)
+func (a *Account) Freeze(by string, reason string) error {
+	if a.IsFrozen() {
+		return ErrAccountFrozen
+	}
+
+	now := time.Now()
+
+	a.Frozen = &Freeze{
+		Reason: reason,
+		By:     by,
+		At:     now,
+	}
+	a.UpdatedAt = now
+
+	return nil
 }
 
-func NewSignTransaction(id string, tx *solana.Transaction, versioned bool) (*Transaction, error) {
+func (a *Account) Unfreeze() error {
+	if !a.IsFrozen() {
+		return errors.New("account is not frozen")
+	}
+
+	a.Frozen = nil
+	a.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// Clone returns a copy that can be modified without affecting a, whose
+// wallets may still be referenced by a repository cache.
+func (a *Account) Clone() *Account {
+	c := *a
+
+	c.Wallets = make([]*Wallet, len(a.Wallets))
+	for i, w := range a.Wallets {
+		cw := *w
+		c.Wallets[i] = &cw
+	}
+
+	return &c
+}
+
+func NewSignTransaction(id string, subject string, wallet int, tx *solana.Transaction, versioned bool) (*Transaction, error) {
 	tid, err := ParseTransactionID(id)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Transaction{
+	t := &Transaction{
 		TransactionID: tid,
+		Subject:       subject,
 		Transaction: &SignTransaction{
+			Wallet:      wallet,
 			Transaction: tx,
 			Versioned:   versioned,
 		},
+	}
+
+	hash, err := t.PayloadHash()
+	if err != nil {
+		return nil, err
+	}
+
+	t.Hash = hash
+	return t, nil
+}
+
+// NewSignBatchTransaction binds a single approval to an ordered list of
+// transactions signed by the same wallet.
+func NewSignBatchTransaction(id string, subject string, wallet int, txs []*solana.Transaction, versioned []bool) (*Transaction, error) {
+	tid, err := ParseTransactionID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(txs) == 0 {
+		return nil, errors.New("empty batch")
+	}
+
+	if len(versioned) != len(txs) {
+		return nil, errors.New("versioned flags do not match transactions")
+	}
+
+	batch := &SignBatch{
+		Transactions: make([]*SignTransaction, len(txs)),
+	}
+
+	for i, tx := range txs {
+		batch.Transactions[i] = &SignTransaction{
+			Wallet:      wallet,
+			Transaction: tx,
+			Versioned:   versioned[i],
+		}
+	}
+
+	t := &Transaction{
+		TransactionID: tid,
+		Subject:       subject,
+		Batch:         batch,
+	}
+
+	hash, err := t.PayloadHash()
+	if err != nil {
+		return nil, err
+	}
+
+	t.Hash = hash
+	return t, nil
+}
+
+func NewDeleteAccountTransaction(id string, subject string) (*Transaction, error) {
+	tid, err := ParseTransactionID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Transaction{
+		TransactionID: tid,
+		Subject:       subject,
+		Deletion:      &DeleteAccount{},
 	}, nil
 }
 
-func NewSignMessageTransaction(id string, msg []byte) (*Transaction, error) {
+func NewExportKeyTransaction(id string, subject string, wallet int, format string) (*Transaction, error) {
 	tid, err := ParseTransactionID(id)
 	if err != nil {
 		return nil, err
@@ -79,10 +415,121 @@ func NewSignMessageTransaction(id string, msg []byte) (*Transaction, error) {
 
 	return &Transaction{
 		TransactionID: tid,
+		Subject:       subject,
+		Export: &ExportKey{
+			Wallet: wallet,
+			Format: format,
+		},
+	}, nil
+}
+
+func NewImportChallengeTransaction(id string, subject string, challenge string) (*Transaction, error) {
+	tid, err := ParseTransactionID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Transaction{
+		TransactionID: tid,
+		Subject:       subject,
+		Import: &ImportChallenge{
+			Challenge: challenge,
+		},
+	}, nil
+}
+
+func NewUnfreezeAccountTransaction(id string, subject string, by string) (*Transaction, error) {
+	tid, err := ParseTransactionID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Transaction{
+		TransactionID: tid,
+		Subject:       subject,
+		Unfreeze: &UnfreezeAccount{
+			By: by,
+		},
+	}, nil
+}
+
+func NewSignMessageTransaction(id string, subject string, wallet int, msg []byte) (*Transaction, error) {
+	tid, err := ParseTransactionID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Transaction{
+		TransactionID: tid,
+		Subject:       subject,
 		Message: &SignMessage{
+			Wallet:  wallet,
 			Message: msg,
 		},
-	}, nil
+	}
+
+	hash, err := t.PayloadHash()
+	if err != nil {
+		return nil, err
+	}
+
+	t.Hash = hash
+	return t, nil
+}
+
+// PayloadHash is the SHA-256 of the unsigned payload, which is what the
+// passkey assertion is bound to.
+func (t *Transaction) PayloadHash() ([32]byte, error) {
+	switch {
+	case t.Message != nil:
+		return sha256.Sum256(t.Message.Message), nil
+
+	case t.Transaction != nil:
+		bs, err := t.Transaction.Transaction.MarshalBinary()
+		if err != nil {
+			return [32]byte{}, err
+		}
+
+		return sha256.Sum256(bs), nil
+
+	case t.Batch != nil:
+		// prefixed with the count so a batch never collides with a single
+		// transaction, then each transaction's digest in order
+		h := sha256.New()
+		h.Write([]byte("wallet:batch:"))
+		binary.Write(h, binary.BigEndian, uint32(len(t.Batch.Transactions)))
+
+		for _, tx := range t.Batch.Transactions {
+			bs, err := tx.Transaction.MarshalBinary()
+			if err != nil {
+				return [32]byte{}, err
+			}
+
+			digest := sha256.Sum256(bs)
+			h.Write(digest[:])
+		}
+
+		var hash [32]byte
+		copy(hash[:], h.Sum(nil))
+		return hash, nil
+
+	default:
+		return [32]byte{}, errors.New("no payload")
+	}
+}
+
+// VerifyPayload checks that the cached payload is still the one approved.
+func (t *Transaction) VerifyPayload() error {
+	hash, err := t.PayloadHash()
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare(hash[:], t.Hash[:]) != 1 {
+		return ErrPayloadMismatch
+	}
+
+	return nil
 }
 
 type TransactionID uuid.UUID
@@ -122,26 +569,60 @@ func (id *TransactionID) UnmarshalJSON(data []byte) error {
 
 type Transaction struct {
 	TransactionID TransactionID    `json:"transaction_id"`
+	Subject       string           `json:"subject,omitempty"`
+	UserID        string           `json:"user_id,omitempty"`
+	Hash          [32]byte         `json:"hash"`
+	ExpiresAt     time.Time        `json:"expires_at"`
 	Transaction   *SignTransaction `json:"transaction"`
+	Summary       *inspect.Summary `json:"summary,omitempty"`
+	Batch         *SignBatch       `json:"batch,omitempty"`
 	Message       *SignMessage     `json:"message"`
+	Deletion      *DeleteAccount   `json:"deletion,omitempty"`
+	Export        *ExportKey       `json:"export,omitempty"`
+	Import        *ImportChallenge `json:"import,omitempty"`
+	Unfreeze      *UnfreezeAccount `json:"unfreeze,omitempty"`
+}
+
+type UnfreezeAccount struct {
+	By string `json:"by"`
+}
+
+type ImportChallenge struct {
+	Challenge string `json:"challenge"`
+}
+
+type DeleteAccount struct{}
+
+type ExportKey struct {
+	Wallet int    `json:"wallet"`
+	Format string `json:"format"`
 }
 
 type SignMessage struct {
-	Message   []byte
-	Signature solana.Signature
+	Wallet  int
+	Message []byte
+}
+
+type SignBatch struct {
+	Transactions []*SignTransaction `json:"transactions"`
+	Summaries    []*inspect.Summary `json:"summaries,omitempty"`
 }
 
 type SignTransaction struct {
+	Wallet      int
 	Transaction *solana.Transaction
 	Versioned   bool
-	Signatures  []solana.Signature
+
+	// Sponsored transactions are co-signed by the fee payer after the wallet.
+	Sponsored bool
 }
 
 func (tx *SignTransaction) UnmarshalJSON(data []byte) error {
 	var raw struct {
-		Transaction []byte             `json:"transaction"`
-		Versioned   bool               `json:"versioned"`
-		Signatures  []solana.Signature `json:"signatures"`
+		Wallet      int    `json:"wallet"`
+		Transaction []byte `json:"transaction"`
+		Versioned   bool   `json:"versioned"`
+		Sponsored   bool   `json:"sponsored"`
 	}
 
 	if err := json.Unmarshal(data, &raw); err != nil {
@@ -153,9 +634,10 @@ func (tx *SignTransaction) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	tx.Wallet = raw.Wallet
 	tx.Transaction = transaction
 	tx.Versioned = raw.Versioned
-	tx.Signatures = raw.Signatures
+	tx.Sponsored = raw.Sponsored
 
 	return nil
 }
@@ -167,12 +649,14 @@ func (tx *SignTransaction) MarshalJSON() ([]byte, error) {
 	}
 
 	return json.Marshal(struct {
-		Transaction []byte             `json:"transaction"`
-		Versioned   bool               `json:"versioned"`
-		Signatures  []solana.Signature `json:"signatures"`
+		Wallet      int    `json:"wallet"`
+		Transaction []byte `json:"transaction"`
+		Versioned   bool   `json:"versioned"`
+		Sponsored   bool   `json:"sponsored,omitempty"`
 	}{
+		Wallet:      tx.Wallet,
 		Transaction: bs,
 		Versioned:   tx.Versioned,
-		Signatures:  tx.Signatures,
+		Sponsored:   tx.Sponsored,
 	})
 }
@@ -0,0 +1,193 @@
+package account
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flarexio/wallet/conf"
+	"github.com/flarexio/wallet/keys"
+)
+
+func TestAccountWallets(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc, err := keys.NewLocalKeysService(&conf.LocalKeyConfig{
+		Name:       "keystore.json",
+		Path:       t.TempDir(),
+		Passphrase: "passphrase",
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer svc.Close()
+
+	key, err := svc.Key(ctx)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	a, err := NewAccount(ctx, "user", key)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(a.Wallets, 1)
+	assert.Equal(DefaultWalletLabel, a.Wallets[0].Label)
+
+	trading, err := a.NewWallet(ctx, key, "trading")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(1, trading.Index)
+	assert.NotEqual(a.Wallet(), trading.PublicKey)
+
+	_, err = a.NewWallet(ctx, key, "trading")
+	assert.ErrorIs(err, ErrWalletLabelExists)
+
+	// wallets are derived deterministically from subject, salt and index
+	privkey, err := DeriveKey(ctx, a.Subject, a.Salt, 1, key)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(trading.PrivateKey, privkey)
+
+	if _, err := a.RenameWallet(1, "savings"); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	_, err = a.RenameWallet(1, DefaultWalletLabel)
+	assert.ErrorIs(err, ErrWalletLabelExists)
+
+	_, err = a.FindWallet(2)
+	assert.ErrorIs(err, ErrWalletNotFound)
+}
+
+func TestLegacyAccount(t *testing.T) {
+	assert := assert.New(t)
+
+	_, privkey, _ := ed25519.GenerateKey(nil)
+
+	legacy := map[string]any{
+		"Subject":    "user",
+		"Salt":       "salt",
+		"KeyVersion": 1,
+		"PrivateKey": []byte(privkey),
+	}
+
+	bs, _ := json.Marshal(legacy)
+
+	var a *Account
+	if err := json.Unmarshal(bs, &a); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(a.Wallets, 1)
+	assert.Equal(0, a.Wallets[0].Index)
+	assert.Equal(ed25519.PrivateKey(privkey), a.Wallets[0].PrivateKey)
+	assert.Equal(solana.PublicKeyFromBytes(privkey.Public().(ed25519.PublicKey)), a.Wallet())
+	assert.False(a.Sealed())
+}
+
+func TestTransactionPayload(t *testing.T) {
+	assert := assert.New(t)
+
+	id := "0b8f0b36-3b5e-4c2f-9a0e-6f1f0f3c2b1a"
+
+	tx, err := NewSignMessageTransaction(id, "user", 0, []byte("hello"))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	bs, err := json.Marshal(tx)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	var cached *Transaction
+	if err := json.Unmarshal(bs, &cached); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.NoError(cached.VerifyPayload())
+	assert.Equal("user", cached.Subject)
+
+	cached.Message.Message = []byte("goodbye")
+	assert.ErrorIs(cached.VerifyPayload(), ErrPayloadMismatch)
+}
+
+func TestBatchPayload(t *testing.T) {
+	assert := assert.New(t)
+
+	id := "0b8f0b36-3b5e-4c2f-9a0e-6f1f0f3c2b1a"
+
+	payer := solana.NewWallet().PublicKey()
+
+	txs := make([]*solana.Transaction, 2)
+	for i := range txs {
+		tx, err := solana.NewTransaction(
+			[]solana.Instruction{
+				solana.NewInstruction(solana.MemoProgramID, nil, []byte{byte('a' + i)}),
+			},
+			solana.Hash{},
+			solana.TransactionPayer(payer),
+		)
+		if err != nil {
+			assert.Fail(err.Error())
+			return
+		}
+
+		txs[i] = tx
+	}
+
+	batch, err := NewSignBatchTransaction(id, "user", 0, txs, []bool{false, false})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	single, err := NewSignTransaction(id, "user", 0, txs[0], false)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.NotEqual(single.Hash, batch.Hash)
+
+	bs, err := json.Marshal(batch)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	var cached *Transaction
+	if err := json.Unmarshal(bs, &cached); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.NoError(cached.VerifyPayload())
+
+	// reordering the batch invalidates the approval
+	ts := cached.Batch.Transactions
+	ts[0], ts[1] = ts[1], ts[0]
+	assert.ErrorIs(cached.VerifyPayload(), ErrPayloadMismatch)
+}
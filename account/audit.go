@@ -0,0 +1,33 @@
+package account
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditAccountDeleted  AuditAction = "account.deleted"
+	AuditAccountFrozen   AuditAction = "account.frozen"
+	AuditAccountUnfrozen AuditAction = "account.unfrozen"
+	AuditKeyExported     AuditAction = "key.exported"
+	AuditKeyImported     AuditAction = "key.imported"
+
+	AuditTransactionSponsored AuditAction = "transaction.sponsored"
+)
+
+// AuditEvent records a sensitive operation on an account. Events outlive the
+// account they refer to.
+type AuditEvent struct {
+	Subject string            `json:"subject"`
+	Action  AuditAction       `json:"action"`
+	Details map[string]string `json:"details,omitempty"`
+	Time    time.Time         `json:"time"`
+}
+
+func NewAuditEvent(subject string, action AuditAction) *AuditEvent {
+	return &AuditEvent{
+		Subject: subject,
+		Action:  action,
+		Details: make(map[string]string),
+		Time:    time.Now(),
+	}
+}
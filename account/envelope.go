@@ -0,0 +1,203 @@
+package account
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"strconv"
+
+	"github.com/gagliardetto/solana-go"
+
+	"github.com/flarexio/wallet/keys"
+)
+
+// EncryptedKey holds a private key sealed with a per-record data key.
+// The data key is wrapped by a key-encryption key derived from the master
+// key, so only the configured key backend can unwrap it.
+type EncryptedKey struct {
+	KeyVersion int
+	WrappedKey []byte
+	Ciphertext []byte
+}
+
+// context binds key material to its account and wallet slot.
+func (a *Account) context(index int) string {
+	if index == 0 {
+		return a.Subject
+	}
+
+	return a.Subject + "/" + strconv.Itoa(index)
+}
+
+func (a *Account) kek(ctx context.Context, key keys.Key, index int) ([]byte, error) {
+	// Ed25519 signatures are deterministic, so the KEK can be re-derived at any time.
+	data := "wallet:kek:" + a.Subject + a.Salt
+	if index > 0 {
+		data += "/" + strconv.Itoa(index)
+	}
+
+	sig, err := key.Signature(ctx, []byte(data))
+	if err != nil {
+		return nil, err
+	}
+
+	kek := sha256.Sum256(sig)
+	return kek[:], nil
+}
+
+// Seal encrypts every plaintext wallet key and drops it from the account.
+func (a *Account) Seal(ctx context.Context, key keys.Key) error {
+	for _, w := range a.Wallets {
+		if w.EncryptedKey != nil && w.PrivateKey == nil {
+			continue
+		}
+
+		if err := a.seal(ctx, key, w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *Account) seal(ctx context.Context, key keys.Key, w *Wallet) error {
+	if len(w.PrivateKey) != ed25519.PrivateKeySize {
+		return errors.New("invalid private key")
+	}
+
+	kek, err := a.kek(ctx, key, w.Index)
+	if err != nil {
+		return err
+	}
+
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return err
+	}
+	defer clear(dek)
+
+	ad := []byte(a.context(w.Index))
+
+	wrapped, err := seal(kek, dek, ad)
+	if err != nil {
+		return err
+	}
+
+	ciphertext, err := seal(dek, w.PrivateKey, ad)
+	if err != nil {
+		return err
+	}
+
+	w.PublicKey = solana.PublicKeyFromBytes(w.PrivateKey.Public().(ed25519.PublicKey))
+	w.EncryptedKey = &EncryptedKey{
+		KeyVersion: key.Version(),
+		WrappedKey: wrapped,
+		Ciphertext: ciphertext,
+	}
+	w.PrivateKey = nil
+
+	return nil
+}
+
+// Unseal decrypts a wallet private key without storing it on the account.
+func (a *Account) Unseal(ctx context.Context, key keys.Key, index int) (ed25519.PrivateKey, error) {
+	w, err := a.FindWallet(index)
+	if err != nil {
+		return nil, err
+	}
+
+	if w.EncryptedKey == nil {
+		if w.PrivateKey == nil {
+			return nil, errors.New("private key not found")
+		}
+
+		return w.PrivateKey, nil
+	}
+
+	if key.Version() != w.EncryptedKey.KeyVersion {
+		return nil, errors.New("invalid key version")
+	}
+
+	kek, err := a.kek(ctx, key, w.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	ad := []byte(a.context(w.Index))
+
+	dek, err := open(kek, w.EncryptedKey.WrappedKey, ad)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(dek)
+
+	privkey, err := open(dek, w.EncryptedKey.Ciphertext, ad)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(privkey) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid private key")
+	}
+
+	pubkey := ed25519.PrivateKey(privkey).Public().(ed25519.PublicKey)
+	if !w.PublicKey.Equals(solana.PublicKeyFromBytes(pubkey)) {
+		return nil, errors.New("public key mismatch")
+	}
+
+	return privkey, nil
+}
+
+// Sealed reports whether no wallet holds a plaintext private key.
+func (a *Account) Sealed() bool {
+	for _, w := range a.Wallets {
+		if w.PrivateKey != nil || w.EncryptedKey == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// seal returns the nonce followed by the ciphertext.
+func seal(key []byte, plaintext []byte, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(key []byte, data []byte, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("invalid ciphertext")
+	}
+
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	return gcm.Open(nil, nonce, ciphertext, additionalData)
+}
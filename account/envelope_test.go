@@ -0,0 +1,103 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flarexio/wallet/conf"
+	"github.com/flarexio/wallet/keys"
+)
+
+func TestSealAccount(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc, err := keys.NewLocalKeysService(&conf.LocalKeyConfig{
+		Name:       "keystore.json",
+		Path:       t.TempDir(),
+		Passphrase: "passphrase",
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer svc.Close()
+
+	key, err := svc.Key(ctx)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	a, err := NewAccount(ctx, "user", key)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if _, err := a.NewWallet(ctx, key, "savings"); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	wallet := a.Wallet()
+	privkey := a.Wallets[0].PrivateKey
+	privkey1 := a.Wallets[1].PrivateKey
+
+	assert.False(a.Sealed())
+
+	if err := a.Seal(ctx, key); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.True(a.Sealed())
+	assert.Nil(a.Wallets[0].PrivateKey)
+	assert.Nil(a.Wallets[1].PrivateKey)
+	assert.Equal(wallet, a.Wallet())
+
+	bs, err := json.Marshal(a)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.NotContains(string(bs), string(privkey))
+
+	var stored *Account
+	if err := json.Unmarshal(bs, &stored); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	unsealed, err := stored.Unseal(ctx, key, 0)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(privkey, unsealed)
+
+	unsealed, err = stored.Unseal(ctx, key, 1)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(privkey1, unsealed)
+
+	// key material swapped between wallet slots must not decrypt
+	stored.Wallets[0].EncryptedKey, stored.Wallets[1].EncryptedKey = stored.Wallets[1].EncryptedKey, stored.Wallets[0].EncryptedKey
+	_, err = stored.Unseal(ctx, key, 0)
+	assert.Error(err)
+
+	// a record moved to another subject must not decrypt
+	stored.Wallets[0].EncryptedKey, stored.Wallets[1].EncryptedKey = stored.Wallets[1].EncryptedKey, stored.Wallets[0].EncryptedKey
+	stored.Subject = "other"
+	_, err = stored.Unseal(ctx, key, 0)
+	assert.Error(err)
+}
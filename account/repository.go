@@ -3,18 +3,74 @@ package account
 import (
 	"errors"
 	"time"
+
+	"github.com/gagliardetto/solana-go"
 )
 
 var (
 	ErrAccountNotFound     = errors.New("account not found")
+	ErrAccountDeleted      = errors.New("account deleted")
+	ErrAccountFrozen       = errors.New("account frozen")
 	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrTransactionExpired  = errors.New("transaction expired")
+	ErrTransactionReplayed = errors.New("transaction already finalized")
+	ErrTransactionMismatch = errors.New("transaction belongs to another user")
+	ErrPayloadMismatch     = errors.New("payload does not match the approved hash")
+	ErrWalletNotFound      = errors.New("wallet not found")
+	ErrWalletLabelExists   = errors.New("wallet label already exists")
+	ErrWalletExists        = errors.New("wallet already exists")
 )
 
+type ListFilter struct {
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	KeyVersion    int
+
+	// Cursor is the subject after which the page starts.
+	Cursor string
+	Limit  int
+}
+
+func (f ListFilter) Match(a *Account) bool {
+	if !f.CreatedAfter.IsZero() && a.CreatedAt.Before(f.CreatedAfter) {
+		return false
+	}
+
+	if !f.CreatedBefore.IsZero() && !a.CreatedAt.Before(f.CreatedBefore) {
+		return false
+	}
+
+	if f.KeyVersion > 0 && a.KeyVersion != f.KeyVersion {
+		return false
+	}
+
+	return true
+}
+
 type Repository interface {
 	Save(a *Account) error
 	Find(subject string) (*Account, error)
+	FindByWallet(wallet solana.PublicKey) (*Account, error)
+	ForEach(fn func(a *Account) error) error
+	List(filter ListFilter) (accounts []*Account, next string, err error)
+
+	// Delete erases the account and leaves a tombstone, so that later
+	// lookups return ErrAccountDeleted and saves are refused. It is
+	// idempotent.
+	Delete(subject string) error
+
+	RecordAudit(e *AuditEvent) error
+	AuditEvents(subject string) ([]*AuditEvent, error)
 
 	CacheTransaction(t *Transaction, ttl time.Duration) error
+
+	// FindTransactionByID reads a cached transaction without consuming it,
+	// with the same errors as RemoveTransactionByID.
+	FindTransactionByID(id TransactionID) (*Transaction, error)
+
+	// RemoveTransactionByID consumes a cached transaction. It returns
+	// ErrTransactionExpired past the TTL and ErrTransactionReplayed once
+	// the transaction has been consumed.
 	RemoveTransactionByID(id TransactionID) (*Transaction, error)
 
 	Close() error
@@ -0,0 +1,184 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/go-webauthn/webauthn/protocol"
+
+	"github.com/flarexio/identity/passkeys"
+	"github.com/flarexio/wallet/account"
+	"github.com/flarexio/wallet/inspect"
+)
+
+// maxBatchTransactions caps how many transactions one passkey approves.
+const maxBatchTransactions = 32
+
+type BatchTransaction struct {
+	Transaction *solana.Transaction
+	Versioned   bool
+}
+
+// BatchSignResult reports the outcome for one transaction of a batch. A
+// failure leaves the remaining transactions unaffected.
+type BatchSignResult struct {
+	Index       int
+	Transaction *solana.Transaction
+	Versioned   bool
+	Signatures  []solana.Signature
+	Err         error
+}
+
+func (svc *service) InitializeSignTransactions(ctx context.Context, req *InitializeSignTransactionsRequest) (*protocol.CredentialAssertion, string, []*TransactionPreview, error) {
+	if len(req.Transactions) == 0 {
+		return nil, "", nil, errors.New("no transactions")
+	}
+
+	if len(req.Transactions) > maxBatchTransactions {
+		return nil, "", nil, fmt.Errorf("batch exceeds %d transactions", maxBatchTransactions)
+	}
+
+	a, err := svc.findActive(req.Subject)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	w, err := a.FindWallet(req.Wallet)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	txs := make([]*solana.Transaction, len(req.Transactions))
+	versioned := make([]bool, len(req.Transactions))
+	for i, tx := range req.Transactions {
+		txs[i] = tx.Transaction
+		versioned[i] = tx.Versioned
+	}
+
+	t, err := account.NewSignBatchTransaction(req.TransactionID, req.Subject, req.Wallet, txs, versioned)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	previews := make([]*TransactionPreview, len(txs))
+	summaries := make([]*inspect.Summary, len(txs))
+	for i, tx := range txs {
+		summary, err := inspect.Inspect(tx)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+
+		preview := &TransactionPreview{
+			Summary: summary,
+		}
+
+		// each transaction is simulated against current state, so later
+		// transactions must not depend on earlier ones landing first
+		if svc.rpc != nil && svc.solana.Simulate {
+			simulation, err := svc.simulate(ctx, w.PublicKey, tx)
+			if err != nil {
+				return nil, "", nil, err
+			}
+
+			if simulation.Err != "" {
+				return nil, "", nil, fmt.Errorf("%w: transaction %d: %s", ErrSimulationFailed, i, simulation.Err)
+			}
+
+			preview.Simulation = simulation
+		}
+
+		previews[i] = preview
+		summaries[i] = summary
+	}
+
+	t.Batch.Summaries = summaries
+
+	r := &passkeys.InitializeTransactionRequest{
+		UserID:          req.UserID,
+		TransactionID:   req.TransactionID,
+		TransactionData: t.Hash,
+	}
+
+	opts, mediation, err := svc.passkeys.InitializeTransaction(r)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	t.UserID = req.UserID
+
+	if err := svc.accounts.CacheTransaction(t, 120*time.Second); err != nil {
+		return nil, "", nil, err
+	}
+
+	return opts, mediation, previews, nil
+}
+
+func (svc *service) FinalizeSignTransactions(ctx context.Context, subject string, req *protocol.ParsedCredentialAssertionData) ([]*BatchSignResult, error) {
+	t, err := svc.finalize(subject, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Batch == nil {
+		return nil, errors.New("invalid transaction")
+	}
+
+	if err := t.VerifyPayload(); err != nil {
+		return nil, err
+	}
+
+	return svc.signBatch(ctx, t.Subject, t.Batch.Transactions)
+}
+
+// signBatch derives the wallet key once and signs every transaction with it,
+// recording per-transaction failures instead of aborting the batch.
+func (svc *service) signBatch(ctx context.Context, subject string, batch []*account.SignTransaction) ([]*BatchSignResult, error) {
+	a, err := svc.findActive(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	// every item of a batch shares the wallet it was initialized with
+	index := batch[0].Wallet
+
+	w, err := a.FindWallet(index)
+	if err != nil {
+		return nil, err
+	}
+
+	privkey, err := svc.privateKey(ctx, a, index)
+	if err != nil {
+		return nil, err
+	}
+
+	getter := func(key solana.PublicKey) *solana.PrivateKey {
+		if key.Equals(w.PublicKey) {
+			return &privkey
+		}
+
+		return nil
+	}
+
+	results := make([]*BatchSignResult, len(batch))
+	for i, item := range batch {
+		result := &BatchSignResult{
+			Index:       i,
+			Transaction: item.Transaction,
+			Versioned:   item.Versioned,
+		}
+
+		sigs, err := item.Transaction.Sign(getter)
+		if err != nil {
+			result.Err = err
+		} else {
+			result.Signatures = sigs
+		}
+
+		results[i] = result
+	}
+
+	return results, nil
+}
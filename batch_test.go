@@ -0,0 +1,78 @@
+package wallet
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flarexio/wallet/account"
+)
+
+func TestSignBatch(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	from, err := svc.Wallet(ctx, "user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	to := solana.NewWallet().PublicKey()
+	stranger := solana.NewWallet().PublicKey()
+
+	signed, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(1_000, from, to).Build(),
+		},
+		solana.Hash{},
+		solana.TransactionPayer(from),
+	)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	// requires a co-signer the wallet does not hold
+	foreign, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(1_000, stranger, to).Build(),
+		},
+		solana.Hash{},
+		solana.TransactionPayer(from),
+	)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	batch := []*account.SignTransaction{
+		{Wallet: 0, Transaction: signed},
+		{Wallet: 0, Transaction: foreign},
+	}
+
+	results, err := svc.signBatch(ctx, "user", batch)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if !assert.Len(results, 2) {
+		return
+	}
+
+	assert.Equal(0, results[0].Index)
+	assert.NoError(results[0].Err)
+	assert.Len(results[0].Signatures, 1)
+	assert.NoError(signed.VerifySignatures())
+
+	assert.Equal(1, results[1].Index)
+	assert.Error(results[1].Err)
+	assert.Empty(results[1].Signatures)
+}
@@ -0,0 +1,308 @@
+package wallet
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+var ErrInvalidBuildRequest = errors.New("invalid build request")
+
+const (
+	// mint layout: mint authority, supply, decimals, initialized, freeze authority
+	mintSize = 82
+
+	// packet size limit for a serialized transaction
+	maxTransactionSize = 1232
+)
+
+const (
+	ataCreateIdempotent  byte = 1
+	tokenTransferChecked byte = 12
+)
+
+// BuiltTransaction is an unsigned transaction ready for signing.
+type BuiltTransaction struct {
+	Transaction          *solana.Transaction
+	FeePayer             solana.PublicKey
+	LastValidBlockHeight uint64
+}
+
+type mintInfo struct {
+	program  solana.PublicKey
+	decimals uint8
+}
+
+func parseMint(acc *rpc.Account) (*mintInfo, error) {
+	if acc == nil || acc.Data == nil {
+		return nil, errors.New("mint not found")
+	}
+
+	if !acc.Owner.Equals(solana.TokenProgramID) && !acc.Owner.Equals(solana.Token2022ProgramID) {
+		return nil, errors.New("not a token mint")
+	}
+
+	// Token-2022 mints may carry extensions after the base layout
+	data := acc.Data.GetBinary()
+	if len(data) < mintSize || data[45] != 1 {
+		return nil, errors.New("not a token mint")
+	}
+
+	return &mintInfo{
+		program:  acc.Owner,
+		decimals: data[44],
+	}, nil
+}
+
+// associatedTokenAddress derives the ATA for either token program;
+// solana.FindAssociatedTokenAddress only covers the original one.
+func associatedTokenAddress(wallet, mint, program solana.PublicKey) (solana.PublicKey, error) {
+	addr, _, err := solana.FindProgramAddress([][]byte{
+		wallet[:],
+		program[:],
+		mint[:],
+	}, solana.SPLAssociatedTokenAccountProgramID)
+
+	return addr, err
+}
+
+func createAssociatedTokenAccountInstruction(payer, ata, owner, mint, program solana.PublicKey) solana.Instruction {
+	return solana.NewInstruction(solana.SPLAssociatedTokenAccountProgramID,
+		solana.AccountMetaSlice{
+			solana.Meta(payer).WRITE().SIGNER(),
+			solana.Meta(ata).WRITE(),
+			solana.Meta(owner),
+			solana.Meta(mint),
+			solana.Meta(solana.SystemProgramID),
+			solana.Meta(program),
+		},
+		[]byte{ataCreateIdempotent},
+	)
+}
+
+func transferCheckedInstruction(program, source, mint, destination, owner solana.PublicKey, amount uint64, decimals uint8) solana.Instruction {
+	data := make([]byte, 10)
+	data[0] = tokenTransferChecked
+	binary.LittleEndian.PutUint64(data[1:9], amount)
+	data[9] = decimals
+
+	return solana.NewInstruction(program,
+		solana.AccountMetaSlice{
+			solana.Meta(source).WRITE(),
+			solana.Meta(mint),
+			solana.Meta(destination).WRITE(),
+			solana.Meta(owner).SIGNER(),
+		},
+		data,
+	)
+}
+
+func memoInstruction(signer solana.PublicKey, memo string) solana.Instruction {
+	return solana.NewInstruction(solana.MemoProgramID,
+		solana.AccountMetaSlice{solana.Meta(signer).SIGNER()},
+		[]byte(memo),
+	)
+}
+
+// tokenTransferInstructions moves amount from the wallet's ATA to the
+// recipient's, creating the latter when missing. The wallet pays the rent so
+// that a sponsor only ever pays fees.
+func tokenTransferInstructions(wallet, to, m solana.PublicKey, info *mintInfo, amount uint64, exists bool) ([]solana.Instruction, error) {
+	source, err := associatedTokenAddress(wallet, m, info.program)
+	if err != nil {
+		return nil, err
+	}
+
+	destination, err := associatedTokenAddress(to, m, info.program)
+	if err != nil {
+		return nil, err
+	}
+
+	var instructions []solana.Instruction
+	if !exists {
+		instructions = append(instructions,
+			createAssociatedTokenAccountInstruction(wallet, destination, to, m, info.program))
+	}
+
+	instructions = append(instructions,
+		transferCheckedInstruction(info.program, source, m, destination, wallet, amount, info.decimals))
+
+	return instructions, nil
+}
+
+// BuildTransfer builds a SOL transfer, or a token transfer when a mint is
+// given. Amounts are in lamports or base token units. Mints with transfer
+// hooks need extra accounts and are not supported.
+func (svc *service) BuildTransfer(ctx context.Context, req *BuildTransferRequest) (*BuiltTransaction, error) {
+	if req.Amount == 0 {
+		return nil, fmt.Errorf("%w: amount is required", ErrInvalidBuildRequest)
+	}
+
+	if req.To.IsZero() {
+		return nil, fmt.Errorf("%w: recipient is required", ErrInvalidBuildRequest)
+	}
+
+	if svc.rpc == nil {
+		return nil, ErrSolanaUnavailable
+	}
+
+	wallet, err := svc.walletKey(req.Subject, req.Wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	var instructions []solana.Instruction
+	if req.Mint == nil {
+		instructions = append(instructions,
+			system.NewTransferInstruction(req.Amount, wallet, req.To).Build())
+	} else {
+		instructions, err = svc.tokenTransfer(ctx, wallet, req.To, *req.Mint, req.Amount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Memo != "" {
+		instructions = append(instructions, memoInstruction(wallet, req.Memo))
+	}
+
+	return svc.build(ctx, wallet, instructions, req.Sponsored)
+}
+
+func (svc *service) BuildMemo(ctx context.Context, req *BuildMemoRequest) (*BuiltTransaction, error) {
+	if req.Memo == "" {
+		return nil, fmt.Errorf("%w: memo is required", ErrInvalidBuildRequest)
+	}
+
+	if svc.rpc == nil {
+		return nil, ErrSolanaUnavailable
+	}
+
+	wallet, err := svc.walletKey(req.Subject, req.Wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	instructions := []solana.Instruction{
+		memoInstruction(wallet, req.Memo),
+	}
+
+	return svc.build(ctx, wallet, instructions, req.Sponsored)
+}
+
+func (svc *service) walletKey(subject string, index int) (solana.PublicKey, error) {
+	a, err := svc.accounts.Find(subject)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+
+	w, err := a.FindWallet(index)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+
+	return w.PublicKey, nil
+}
+
+func (svc *service) tokenTransfer(ctx context.Context, wallet, to, m solana.PublicKey, amount uint64) ([]solana.Instruction, error) {
+	commitment := rpc.CommitmentType(svc.solana.Commitment)
+
+	result, err := svc.rpc.GetAccountInfoWithOpts(ctx, m, &rpc.GetAccountInfoOpts{
+		Encoding:   solana.EncodingBase64,
+		Commitment: commitment,
+	})
+	if err != nil && !errors.Is(err, rpc.ErrNotFound) {
+		return nil, err
+	}
+
+	var acc *rpc.Account
+	if result != nil {
+		acc = result.Value
+	}
+
+	info, err := parseMint(acc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBuildRequest, err)
+	}
+
+	destination, err := associatedTokenAddress(to, m, info.program)
+	if err != nil {
+		return nil, err
+	}
+
+	exists := true
+	if _, err := svc.rpc.GetAccountInfoWithOpts(ctx, destination, &rpc.GetAccountInfoOpts{
+		Encoding:   solana.EncodingBase64,
+		Commitment: commitment,
+	}); err != nil {
+		if !errors.Is(err, rpc.ErrNotFound) {
+			return nil, err
+		}
+
+		exists = false
+	}
+
+	return tokenTransferInstructions(wallet, to, m, info, amount, exists)
+}
+
+// build assembles the instructions with a recent blockhash. A sponsored
+// transaction is checked against the sponsor rules here as well, so the
+// caller learns about a refusal before any passkey ceremony.
+func (svc *service) build(ctx context.Context, wallet solana.PublicKey, instructions []solana.Instruction, sponsored bool) (*BuiltTransaction, error) {
+	feePayer := wallet
+	if sponsored {
+		if svc.sponsor == nil {
+			return nil, ErrSponsorUnavailable
+		}
+
+		feePayer = svc.sponsor.FeePayer()
+	}
+
+	latest, err := svc.rpc.GetLatestBlockhash(ctx, rpc.CommitmentType(svc.solana.Commitment))
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := assemble(instructions, latest.Value.Blockhash, feePayer)
+	if err != nil {
+		return nil, err
+	}
+
+	if sponsored {
+		if _, err := svc.sponsor.check(tx); err != nil {
+			return nil, err
+		}
+	}
+
+	return &BuiltTransaction{
+		Transaction:          tx,
+		FeePayer:             feePayer,
+		LastValidBlockHeight: latest.Value.LastValidBlockHeight,
+	}, nil
+}
+
+func assemble(instructions []solana.Instruction, blockhash solana.Hash, feePayer solana.PublicKey) (*solana.Transaction, error) {
+	tx, err := solana.NewTransaction(instructions, blockhash, solana.TransactionPayer(feePayer))
+	if err != nil {
+		return nil, err
+	}
+
+	// zeroed signatures give the wire format and size of the signed transaction
+	tx.Signatures = make([]solana.Signature, tx.Message.Header.NumRequiredSignatures)
+
+	bs, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bs) > maxTransactionSize {
+		return nil, fmt.Errorf("%w: transaction exceeds %d bytes", ErrInvalidBuildRequest, maxTransactionSize)
+	}
+
+	return tx, nil
+}
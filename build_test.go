@@ -0,0 +1,234 @@
+package wallet
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMint(t *testing.T) {
+	assert := assert.New(t)
+
+	data := make([]byte, mintSize)
+	binary.LittleEndian.PutUint64(data[36:44], 1_000_000)
+	data[44] = 6
+	data[45] = 1
+
+	acc := &rpc.Account{
+		Owner: solana.Token2022ProgramID,
+		Data:  rpc.DataBytesOrJSONFromBytes(data),
+	}
+
+	m, err := parseMint(acc)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(solana.Token2022ProgramID, m.program)
+	assert.Equal(uint8(6), m.decimals)
+
+	data[45] = 0
+	_, err = parseMint(acc)
+	assert.Error(err)
+
+	acc.Owner = solana.SystemProgramID
+	_, err = parseMint(acc)
+	assert.Error(err)
+
+	_, err = parseMint(nil)
+	assert.Error(err)
+}
+
+func TestAssociatedTokenAddress(t *testing.T) {
+	assert := assert.New(t)
+
+	wallet := solana.NewWallet().PublicKey()
+	mint := solana.NewWallet().PublicKey()
+
+	expected, _, err := solana.FindAssociatedTokenAddress(wallet, mint)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	ata, err := associatedTokenAddress(wallet, mint, solana.TokenProgramID)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(expected, ata)
+
+	ata, err = associatedTokenAddress(wallet, mint, solana.Token2022ProgramID)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.NotEqual(expected, ata)
+}
+
+func TestTokenTransferInstructions(t *testing.T) {
+	assert := assert.New(t)
+
+	wallet := solana.NewWallet().PublicKey()
+	to := solana.NewWallet().PublicKey()
+	mint := solana.NewWallet().PublicKey()
+	info := &mintInfo{program: solana.Token2022ProgramID, decimals: 6}
+
+	instructions, err := tokenTransferInstructions(wallet, to, mint, info, 1_500_000, false)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if !assert.Len(instructions, 2) {
+		return
+	}
+
+	destination, _ := associatedTokenAddress(to, mint, solana.Token2022ProgramID)
+
+	create := instructions[0]
+	assert.Equal(solana.SPLAssociatedTokenAccountProgramID, create.ProgramID())
+	assert.Equal(destination, create.Accounts()[1].PublicKey)
+	assert.Equal(solana.Token2022ProgramID, create.Accounts()[5].PublicKey)
+
+	data, _ := create.Data()
+	assert.Equal([]byte{ataCreateIdempotent}, data)
+
+	transfer := instructions[1]
+	assert.Equal(solana.Token2022ProgramID, transfer.ProgramID())
+	assert.Equal(destination, transfer.Accounts()[2].PublicKey)
+
+	data, _ = transfer.Data()
+	assert.Equal(tokenTransferChecked, data[0])
+	assert.Equal(uint64(1_500_000), binary.LittleEndian.Uint64(data[1:9]))
+	assert.Equal(uint8(6), data[9])
+
+	instructions, err = tokenTransferInstructions(wallet, to, mint, info, 1_500_000, true)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(instructions, 1)
+
+	// the wallet pays the rent, so a sponsor accepts the transaction
+	s := newTestSponsor(t, 10_000, 1_000_000)
+	s.programs = append(s.programs, solana.SPLAssociatedTokenAccountProgramID, solana.Token2022ProgramID)
+
+	instructions, _ = tokenTransferInstructions(wallet, to, mint, info, 1_500_000, false)
+
+	tx, err := assemble(instructions, solana.Hash{1}, s.FeePayer())
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(tx.Signatures, 2)
+
+	_, err = s.check(tx)
+	assert.NoError(err)
+}
+
+func TestAssemble(t *testing.T) {
+	assert := assert.New(t)
+
+	wallet := solana.NewWallet().PublicKey()
+
+	tx, err := assemble([]solana.Instruction{memoInstruction(wallet, "hello")}, solana.Hash{1}, wallet)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	bs, err := tx.MarshalBinary()
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	// round trips into the shape InitializeSignTransaction accepts
+	decoded, err := solana.TransactionFromBytes(bs)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(wallet, decoded.Message.AccountKeys[0])
+	assert.Len(decoded.Signatures, 1)
+
+	memo := strings.Repeat("a", maxTransactionSize)
+	_, err = assemble([]solana.Instruction{memoInstruction(wallet, memo)}, solana.Hash{1}, wallet)
+	assert.ErrorIs(err, ErrInvalidBuildRequest)
+}
+
+// TestBuildTransfer runs against a local solana-test-validator, e.g.
+// SOLANA_TEST_VALIDATOR_RPC=http://127.0.0.1:8899
+func TestBuildTransfer(t *testing.T) {
+	endpoint := os.Getenv("SOLANA_TEST_VALIDATOR_RPC")
+	if endpoint == "" {
+		t.Skip("SOLANA_TEST_VALIDATOR_RPC is not set")
+	}
+
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	svc.rpc = rpc.New(endpoint)
+	svc.solana.Commitment = "confirmed"
+
+	from, err := svc.Wallet(ctx, "user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if err := airdrop(ctx, svc.rpc, from); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	built, err := svc.BuildTransfer(ctx, &BuildTransferRequest{
+		Subject: "user",
+		To:      solana.NewWallet().PublicKey(),
+		Amount:  1_000_000,
+		Memo:    "rent",
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(from, built.FeePayer)
+	assert.Positive(built.LastValidBlockHeight)
+
+	simulation, err := svc.simulate(ctx, from, built.Transaction)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Empty(simulation.Err)
+	assert.Equal(int64(-1_005_000), simulation.SOLDelta)
+
+	// a random mint does not exist
+	mint := solana.NewWallet().PublicKey()
+
+	_, err = svc.BuildTransfer(ctx, &BuildTransferRequest{
+		Subject: "user",
+		To:      solana.NewWallet().PublicKey(),
+		Mint:    &mint,
+		Amount:  1,
+	})
+	assert.True(errors.Is(err, ErrInvalidBuildRequest))
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -36,6 +37,23 @@ func main() {
 				Value:   8080,
 			},
 		},
+		Commands: []*cli.Command{
+			{
+				Name:  "recover",
+				Usage: "verify stored accounts against keys re-derived from the master key",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "subject",
+						Usage: "subjects to verify (default: all accounts)",
+					},
+					&cli.BoolFlag{
+						Name:  "restore",
+						Usage: "restore missing or unreadable private keys",
+					},
+				},
+				Action: recoverAccounts,
+			},
+		},
 		Action: run,
 	}
 
@@ -49,12 +67,14 @@ func main() {
 	}
 }
 
-func run(ctx context.Context, cmd *cli.Command) error {
+func loadConfig(cmd *cli.Command) (conf.Config, error) {
+	var cfg conf.Config
+
 	path := cmd.String("path")
 	if path == "" {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
-			return err
+			return cfg, err
 		}
 
 		path = homeDir + "/.flarex/wallet"
@@ -64,11 +84,84 @@ func run(ctx context.Context, cmd *cli.Command) error {
 
 	f, err := os.Open(conf.Path + "/config.yaml")
 	if err != nil {
-		return err
+		return cfg, err
 	}
+	defer f.Close()
 
-	var cfg conf.Config
 	err = yaml.NewDecoder(f).Decode(&cfg)
+	return cfg, err
+}
+
+func recoverAccounts(ctx context.Context, cmd *cli.Command) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	passkeysSvc, err := passkeys.NewService(cfg.Passkeys)
+	if err != nil {
+		return err
+	}
+
+	repo, err := persistence.NewAccountRepository(cfg.Persistence)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	svc, err := wallet.NewService(repo, passkeysSvc, cfg)
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	restore := cmd.Bool("restore")
+
+	var results []*wallet.RecoveryResult
+	if subjects := cmd.StringSlice("subject"); len(subjects) > 0 {
+		for _, subject := range subjects {
+			subjectResults, err := svc.RecoverAccount(ctx, subject, restore)
+			if err != nil {
+				subjectResults = []*wallet.RecoveryResult{{
+					Subject: subject,
+					Status:  wallet.RecoveryStatusFailed,
+					Error:   err.Error(),
+				}}
+			}
+
+			results = append(results, subjectResults...)
+		}
+	} else {
+		results, err = svc.RecoverAccounts(ctx, restore)
+		if err != nil {
+			return err
+		}
+	}
+
+	var failed int
+	for _, result := range results {
+		fmt.Printf("%s\t%d\t%s\tv%d\t%s", result.Subject, result.Index, result.Wallet, result.KeyVersion, result.Status)
+		if result.Error != "" {
+			fmt.Printf("\t%s", result.Error)
+		}
+		fmt.Println()
+
+		switch result.Status {
+		case wallet.RecoveryStatusVerified, wallet.RecoveryStatusRestored:
+		default:
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d accounts failed verification", failed, len(results))
+	}
+
+	return nil
+}
+
+func run(ctx context.Context, cmd *cli.Command) error {
+	cfg, err := loadConfig(cmd)
 	if err != nil {
 		return err
 	}
@@ -100,7 +193,7 @@ func run(ctx context.Context, cmd *cli.Command) error {
 
 	http.Init(ctx, cfg.JWT)
 
-	permissionsPath := filepath.Join(path, "permissions.json")
+	permissionsPath := filepath.Join(conf.Path, "permissions.json")
 	policy, err := policy.NewRegoPolicy(ctx, permissionsPath)
 	if err != nil {
 		return err
@@ -113,6 +206,14 @@ func run(ctx context.Context, cmd *cli.Command) error {
 		// GET /health
 		api.GET("/health", http.HealthHandler)
 
+		// POST /verifications
+		// POST /accounts/:user/verifications
+		{
+			endpoint := wallet.VerifyEndpoint(svc)
+			api.POST("/verifications", http.VerifyHandler(endpoint))
+			api.POST("/accounts/:user/verifications", http.VerifyHandler(endpoint))
+		}
+
 		// GET /accounts/:user
 		{
 			endpoint := wallet.WalletEndpoint(svc)
@@ -120,6 +221,76 @@ func run(ctx context.Context, cmd *cli.Command) error {
 				http.WalletHandler(endpoint))
 		}
 
+		// POST /accounts/:user/freeze
+		{
+			endpoint := wallet.FreezeAccountEndpoint(svc)
+			api.POST("/accounts/:user/freeze", auth("wallet::accounts.freeze", http.Owner, http.Admin),
+				http.FreezeAccountHandler(endpoint))
+		}
+
+		// POST /accounts/:user/deletion
+		{
+			endpoint := wallet.InitializeDeleteAccountEndpoint(svc)
+			api.POST("/accounts/:user/deletion", auth("wallet::accounts.delete", http.Owner),
+				http.InitializeDeleteAccountHandler(endpoint))
+		}
+
+		// PUT /accounts/:user/deletion
+		{
+			endpoint := wallet.FinalizeDeleteAccountEndpoint(svc)
+			api.PUT("/accounts/:user/deletion", auth("wallet::accounts.delete", http.Owner),
+				http.FinalizeDeleteAccountHandler(endpoint))
+		}
+
+		// POST /accounts/:user/key-exports
+		{
+			endpoint := wallet.InitializeExportKeyEndpoint(svc)
+			api.POST("/accounts/:user/key-exports", auth("wallet::accounts.export", http.Owner),
+				http.InitializeExportKeyHandler(endpoint))
+		}
+
+		// PUT /accounts/:user/key-exports
+		{
+			endpoint := wallet.FinalizeExportKeyEndpoint(svc)
+			api.PUT("/accounts/:user/key-exports", auth("wallet::accounts.export", http.Owner),
+				http.FinalizeExportKeyHandler(endpoint))
+		}
+
+		// GET /accounts/:user/wallets
+		{
+			endpoint := wallet.WalletsEndpoint(svc)
+			api.GET("/accounts/:user/wallets", auth("wallet::accounts.get", http.Owner),
+				http.WalletHandler(endpoint))
+		}
+
+		// POST /accounts/:user/wallets
+		{
+			endpoint := wallet.CreateWalletEndpoint(svc)
+			api.POST("/accounts/:user/wallets", auth("wallet::accounts.update", http.Owner),
+				http.CreateWalletHandler(endpoint))
+		}
+
+		// POST /accounts/:user/import-challenges
+		{
+			endpoint := wallet.CreateImportChallengeEndpoint(svc)
+			api.POST("/accounts/:user/import-challenges", auth("wallet::accounts.import", http.Owner),
+				http.WalletHandler(endpoint))
+		}
+
+		// POST /accounts/:user/wallets/imports
+		{
+			endpoint := wallet.ImportWalletEndpoint(svc)
+			api.POST("/accounts/:user/wallets/imports", auth("wallet::accounts.import", http.Owner),
+				http.ImportWalletHandler(endpoint))
+		}
+
+		// PATCH /accounts/:user/wallets/:index
+		{
+			endpoint := wallet.RenameWalletEndpoint(svc)
+			api.PATCH("/accounts/:user/wallets/:index", auth("wallet::accounts.update", http.Owner),
+				http.RenameWalletHandler(endpoint))
+		}
+
 		// POST /accounts/:user/message-signatures
 		{
 			endpoint := wallet.InitializeSignMessageEndpoint(svc)
@@ -134,6 +305,27 @@ func run(ctx context.Context, cmd *cli.Command) error {
 				http.FinalizeSignMessageHandler(endpoint))
 		}
 
+		// POST /accounts/:user/offchain-message-signatures
+		{
+			endpoint := wallet.InitializeSignOffchainMessageEndpoint(svc)
+			api.POST("/accounts/:user/offchain-message-signatures", auth("wallet::accounts.get", http.Owner),
+				http.InitializeSignOffchainMessageHandler(endpoint))
+		}
+
+		// POST /accounts/:user/sign-ins
+		{
+			endpoint := wallet.InitializeSignInEndpoint(svc)
+			api.POST("/accounts/:user/sign-ins", auth("wallet::accounts.get", http.Owner),
+				http.InitializeSignInHandler(endpoint))
+		}
+
+		// POST /accounts/:user/sign-in-verifications
+		{
+			endpoint := wallet.VerifySignInEndpoint(svc)
+			api.POST("/accounts/:user/sign-in-verifications", auth("wallet::accounts.get", http.Owner),
+				http.VerifySignInHandler(endpoint))
+		}
+
 		// POST /accounts/:user/transaction-signatures
 		{
 			endpoint := wallet.InitializeSignTransactionEndpoint(svc)
@@ -148,6 +340,85 @@ func run(ctx context.Context, cmd *cli.Command) error {
 				http.FinalizeSignTransactionHandler(endpoint))
 		}
 
+		// GET /accounts/:user/portfolio
+		{
+			endpoint := wallet.PortfolioEndpoint(svc)
+			api.GET("/accounts/:user/portfolio", auth("wallet::accounts.get", http.Owner),
+				http.PortfolioHandler(endpoint))
+		}
+
+		// GET /accounts/:user/sponsor
+		{
+			endpoint := wallet.SponsorEndpoint(svc)
+			api.GET("/accounts/:user/sponsor", auth("wallet::accounts.get", http.Owner),
+				http.WalletHandler(endpoint))
+		}
+
+		// POST /accounts/:user/transfer-transactions
+		{
+			endpoint := wallet.BuildTransferEndpoint(svc)
+			api.POST("/accounts/:user/transfer-transactions", auth("wallet::accounts.get", http.Owner),
+				http.BuildTransferHandler(endpoint))
+		}
+
+		// POST /accounts/:user/memo-transactions
+		{
+			endpoint := wallet.BuildMemoEndpoint(svc)
+			api.POST("/accounts/:user/memo-transactions", auth("wallet::accounts.get", http.Owner),
+				http.BuildMemoHandler(endpoint))
+		}
+
+		// POST /accounts/:user/transaction-batch-signatures
+		{
+			endpoint := wallet.InitializeSignTransactionsEndpoint(svc)
+			api.POST("/accounts/:user/transaction-batch-signatures", auth("wallet::accounts.get", http.Owner),
+				http.InitializeSignTransactionsHandler(endpoint))
+		}
+
+		// PUT /accounts/:user/transaction-batch-signatures
+		{
+			endpoint := wallet.FinalizeSignTransactionsEndpoint(svc)
+			api.PUT("/accounts/:user/transaction-batch-signatures", auth("wallet::accounts.get", http.Owner),
+				http.FinalizeSignTransactionHandler(endpoint))
+		}
+
+		// PUT /accounts/:user/transaction-submissions
+		{
+			endpoint := wallet.FinalizeSubmitTransactionEndpoint(svc)
+			api.PUT("/accounts/:user/transaction-submissions", auth("wallet::accounts.get", http.Owner),
+				http.FinalizeSubmitTransactionHandler(endpoint))
+		}
+
+		admin := api.Group("/admin")
+
+		// GET /admin/accounts
+		{
+			endpoint := wallet.ListAccountsEndpoint(svc)
+			admin.GET("/accounts", auth("wallet::admin.list", http.Admin),
+				http.ListAccountsHandler(endpoint))
+		}
+
+		// GET /admin/accounts/:user
+		{
+			endpoint := wallet.AccountEndpoint(svc)
+			admin.GET("/accounts/:user", auth("wallet::admin.get", http.Admin),
+				http.WalletHandler(endpoint))
+		}
+
+		// POST /admin/accounts/:user/unfreeze
+		{
+			endpoint := wallet.InitializeUnfreezeAccountEndpoint(svc)
+			admin.POST("/accounts/:user/unfreeze", auth("wallet::admin.unfreeze", http.Admin),
+				http.InitializeUnfreezeAccountHandler(endpoint))
+		}
+
+		// PUT /admin/accounts/:user/unfreeze
+		{
+			endpoint := wallet.FinalizeUnfreezeAccountEndpoint(svc)
+			admin.PUT("/accounts/:user/unfreeze", auth("wallet::admin.unfreeze", http.Admin),
+				http.FinalizeUnfreezeAccountHandler(endpoint))
+		}
+
 		// POST /sessions
 		{
 			endpoint := wallet.CreateSessionEndpoint(svc)
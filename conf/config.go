@@ -2,6 +2,8 @@ package conf
 
 import (
 	"fmt"
+	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
@@ -17,18 +19,163 @@ type Config struct {
 	Persistence PersistenceConfig     `yaml:"persistence"`
 	JWT         JWTConfig             `yaml:"jwt"`
 	Passkeys    conf.PasskeysProvider `yaml:"passkeys"`
+	Solana      SolanaConfig          `yaml:"solana"`
+	Sponsor     *SponsorConfig        `yaml:"sponsor"`
+}
+
+type KeyDriver int
+
+const (
+	KeyDriverGoogle KeyDriver = iota
+	KeyDriverLocal
+	KeyDriverVault
+	KeyDriverPKCS11
+)
+
+func ParseKeyDriver(value string) (KeyDriver, error) {
+	switch value {
+	case "google", "":
+		return KeyDriverGoogle, nil
+	case "local":
+		return KeyDriverLocal, nil
+	case "vault":
+		return KeyDriverVault, nil
+	case "pkcs11":
+		return KeyDriverPKCS11, nil
+	default:
+		return -1, fmt.Errorf("unknown key driver")
+	}
 }
 
 type KeyConfig struct {
-	Google  GoogleKeyConfig  `yaml:"google"`
-	Session SessionKeyConfig `yaml:"session"`
+	Driver  KeyDriver
+	Google  *GoogleKeyConfig
+	Local   *LocalKeyConfig
+	Vault   *VaultKeyConfig
+	PKCS11  *PKCS11KeyConfig
+	Session SessionKeyConfig
+}
+
+func (cfg *KeyConfig) UnmarshalYAML(value *yaml.Node) error {
+	var raw struct {
+		Driver  string           `yaml:"driver"`
+		Google  *GoogleKeyConfig `yaml:"google"`
+		Local   *LocalKeyConfig  `yaml:"local"`
+		Vault   *VaultKeyConfig  `yaml:"vault"`
+		PKCS11  *PKCS11KeyConfig `yaml:"pkcs11"`
+		Session SessionKeyConfig `yaml:"session"`
+	}
+
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+
+	driver, err := ParseKeyDriver(raw.Driver)
+	if err != nil {
+		return err
+	}
+
+	cfg.Driver = driver
+	cfg.Google = raw.Google
+	cfg.Local = raw.Local
+	cfg.Vault = raw.Vault
+	cfg.PKCS11 = raw.PKCS11
+	cfg.Session = raw.Session
+
+	return nil
 }
 
 type GoogleKeyConfig struct {
-	ProjectID string `yaml:"projectID"`
-	Location  string `yaml:"location"`
-	KeyRing   string `yaml:"keyRing"`
-	Key       string `yaml:"key"`
+	ProjectID       string        `yaml:"projectID"`
+	Location        string        `yaml:"location"`
+	KeyRing         string        `yaml:"keyRing"`
+	Key             string        `yaml:"key"`
+	RefreshInterval time.Duration `yaml:"refreshInterval"`
+	Timeout         time.Duration `yaml:"timeout"`
+	Retry           RetryConfig   `yaml:"retry"`
+}
+
+type RetryConfig struct {
+	Attempts   int           `yaml:"attempts"`
+	Backoff    time.Duration `yaml:"backoff"`
+	MaxBackoff time.Duration `yaml:"maxBackoff"`
+}
+
+type VaultKeyConfig struct {
+	Address string
+	Token   string
+	Mount   string
+	Key     string
+	Timeout time.Duration
+	Retry   RetryConfig
+}
+
+func (cfg *VaultKeyConfig) UnmarshalYAML(value *yaml.Node) error {
+	var raw struct {
+		Address string        `yaml:"address"`
+		Token   string        `yaml:"token"`
+		Mount   string        `yaml:"mount"`
+		Key     string        `yaml:"key"`
+		Timeout time.Duration `yaml:"timeout"`
+		Retry   RetryConfig   `yaml:"retry"`
+	}
+
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+
+	cfg.Address = raw.Address
+	if raw.Address == "" {
+		cfg.Address = os.Getenv("VAULT_ADDR")
+	}
+
+	cfg.Token = raw.Token
+	if raw.Token == "" {
+		cfg.Token = os.Getenv("VAULT_TOKEN")
+	}
+
+	cfg.Mount = raw.Mount
+	if raw.Mount == "" {
+		cfg.Mount = "transit"
+	}
+
+	cfg.Key = raw.Key
+	cfg.Timeout = raw.Timeout
+	cfg.Retry = raw.Retry
+
+	return nil
+}
+
+type PKCS11KeyConfig struct {
+	Library string
+	Token   string
+	PIN     string
+	Label   string
+}
+
+func (cfg *PKCS11KeyConfig) UnmarshalYAML(value *yaml.Node) error {
+	var raw struct {
+		Library string `yaml:"library"`
+		Token   string `yaml:"token"`
+		PIN     string `yaml:"pin"`
+		Label   string `yaml:"label"`
+	}
+
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+
+	cfg.Library = raw.Library
+	cfg.Token = raw.Token
+
+	cfg.PIN = raw.PIN
+	if raw.PIN == "" {
+		cfg.PIN = os.Getenv("WALLET_PKCS11_PIN")
+	}
+
+	cfg.Label = raw.Label
+
+	return nil
 }
 
 type SessionKeyConfig struct {
@@ -40,6 +187,41 @@ func (key GoogleKeyConfig) Path() string {
 		key.ProjectID, key.Location, key.KeyRing, key.Key)
 }
 
+type LocalKeyConfig struct {
+	Name       string
+	Path       string
+	Passphrase string
+}
+
+func (cfg *LocalKeyConfig) UnmarshalYAML(value *yaml.Node) error {
+	var raw struct {
+		Name       string `yaml:"name"`
+		Path       string `yaml:"path"`
+		Passphrase string `yaml:"passphrase"`
+	}
+
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+
+	cfg.Name = raw.Name
+	if raw.Name == "" {
+		cfg.Name = "keystore.json"
+	}
+
+	cfg.Path = raw.Path
+	if raw.Path == "" {
+		cfg.Path = Path
+	}
+
+	cfg.Passphrase = raw.Passphrase
+	if raw.Passphrase == "" {
+		cfg.Passphrase = os.Getenv("WALLET_KEYSTORE_PASSPHRASE")
+	}
+
+	return nil
+}
+
 type PersistenceDriver int
 
 const (
@@ -163,3 +345,86 @@ type JWTConfig struct {
 	Audience string `yaml:"audience"`
 	JWKsURL  string `yaml:"jwksURL"`
 }
+
+// SolanaConfig points the service at a cluster. Without an RPC endpoint,
+// features that need the chain are disabled.
+type SolanaConfig struct {
+	RPC          string
+	Commitment   string
+	Simulate     bool
+	PortfolioTTL time.Duration
+}
+
+func (cfg *SolanaConfig) UnmarshalYAML(value *yaml.Node) error {
+	var raw struct {
+		RPC          string        `yaml:"rpc"`
+		Commitment   string        `yaml:"commitment"`
+		Simulate     bool          `yaml:"simulate"`
+		PortfolioTTL time.Duration `yaml:"portfolioTTL"`
+	}
+
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+
+	cfg.RPC = raw.RPC
+
+	cfg.Commitment = raw.Commitment
+	if raw.Commitment == "" {
+		cfg.Commitment = "confirmed"
+	}
+
+	cfg.Simulate = raw.Simulate
+
+	cfg.PortfolioTTL = raw.PortfolioTTL
+	if raw.PortfolioTTL == 0 {
+		cfg.PortfolioTTL = 10 * time.Second
+	}
+
+	return nil
+}
+
+// SponsorConfig enables gasless transactions. The fee payer keypair is a
+// keygen file, loaded like SolanaPersistenceConfig.Account. Fees are in
+// lamports; the budget is per subject over a rolling day.
+type SponsorConfig struct {
+	Path        string
+	Account     string
+	Programs    []string
+	MaxFee      uint64
+	DailyBudget uint64
+}
+
+func (cfg *SponsorConfig) UnmarshalYAML(value *yaml.Node) error {
+	var raw struct {
+		Path        string   `yaml:"path"`
+		Account     string   `yaml:"account"`
+		Programs    []string `yaml:"programs"`
+		MaxFee      uint64   `yaml:"maxFee"`
+		DailyBudget uint64   `yaml:"dailyBudget"`
+	}
+
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+
+	cfg.Path = raw.Path
+	if raw.Path == "" {
+		cfg.Path = Path
+	}
+
+	cfg.Account = raw.Account
+	cfg.Programs = raw.Programs
+
+	cfg.MaxFee = raw.MaxFee
+	if raw.MaxFee == 0 {
+		cfg.MaxFee = 50_000
+	}
+
+	cfg.DailyBudget = raw.DailyBudget
+	if raw.DailyBudget == 0 {
+		cfg.DailyBudget = 1_000_000
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package conf
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v3"
@@ -26,10 +27,26 @@ func TestConfig(t *testing.T) {
 		return
 	}
 
+	assert.Equal(KeyDriverGoogle, cfg.Keys.Driver)
 	assert.Equal("flarex-439501", cfg.Keys.Google.ProjectID)
 	assert.Equal("global", cfg.Keys.Google.Location)
 	assert.Equal("wallet", cfg.Keys.Google.KeyRing)
 	assert.Equal("main", cfg.Keys.Google.Key)
+	assert.Equal(5*time.Minute, cfg.Keys.Google.RefreshInterval)
+	assert.Equal(10*time.Second, cfg.Keys.Google.Timeout)
+	assert.Equal(3, cfg.Keys.Google.Retry.Attempts)
+	assert.Equal(100*time.Millisecond, cfg.Keys.Google.Retry.Backoff)
+	assert.Equal(2*time.Second, cfg.Keys.Google.Retry.MaxBackoff)
+
+	assert.Equal("keystore.json", cfg.Keys.Local.Name)
+	assert.Equal(Path, cfg.Keys.Local.Path)
+
+	assert.Equal("transit", cfg.Keys.Vault.Mount)
+	assert.Equal("wallet", cfg.Keys.Vault.Key)
+	assert.Equal(10*time.Second, cfg.Keys.Vault.Timeout)
+
+	assert.Equal("wallet", cfg.Keys.PKCS11.Token)
+	assert.Equal("wallet", cfg.Keys.PKCS11.Label)
 
 	assert.Len(cfg.Keys.Session.Key, 32)
 
@@ -48,7 +65,47 @@ func TestConfig(t *testing.T) {
 	assert.Equal(Path, composite.Main.Solana.Path)
 	assert.Equal("id.json", composite.Main.Solana.Account)
 
+	assert.Equal("https://api.devnet.solana.com", cfg.Solana.RPC)
+	assert.Equal("confirmed", cfg.Solana.Commitment)
+	assert.True(cfg.Solana.Simulate)
+	assert.Equal(10*time.Second, cfg.Solana.PortfolioTTL)
+
+	// sponsorship is opt-in
+	assert.Nil(cfg.Sponsor)
+
 	assert.Equal("identity.flarex.io", cfg.JWT.Issuer)
 	assert.Equal("talkix.flarex.io", cfg.JWT.Audience)
 	assert.Equal("https://identity.flarex.io/.well-known/jwks.json", cfg.JWT.JWKsURL)
 }
+
+func TestSponsorConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	Path = "~/.flarex/wallet"
+
+	raw := `
+sponsor:
+  account: sponsor.json
+  programs:
+  - TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA
+  - MemoSq4gqABAXKb96qnH8TysNcWxMyWCqXgDLGmfcHr
+  maxFee: 50000
+  dailyBudget: 1000000
+`
+
+	var cfg Config
+	if err := yaml.Unmarshal([]byte(raw), &cfg); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if !assert.NotNil(cfg.Sponsor) {
+		return
+	}
+
+	assert.Equal(Path, cfg.Sponsor.Path)
+	assert.Equal("sponsor.json", cfg.Sponsor.Account)
+	assert.Len(cfg.Sponsor.Programs, 2)
+	assert.Equal(uint64(50_000), cfg.Sponsor.MaxFee)
+	assert.Equal(uint64(1_000_000), cfg.Sponsor.DailyBudget)
+}
@@ -4,12 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/go-kit/kit/endpoint"
 	"github.com/go-webauthn/webauthn/protocol"
 
 	"github.com/flarexio/identity/passkeys"
+	"github.com/flarexio/wallet/account"
+	"github.com/flarexio/wallet/inspect"
+	"github.com/flarexio/wallet/siws"
 )
 
 func WalletEndpoint(svc Service) endpoint.Endpoint {
@@ -19,12 +24,408 @@ func WalletEndpoint(svc Service) endpoint.Endpoint {
 			return nil, errors.New("invalid request")
 		}
 
-		return svc.Wallet(sub)
+		return svc.Wallet(ctx, sub)
+	}
+}
+
+type WalletResponse struct {
+	Index     int              `json:"index"`
+	Label     string           `json:"label"`
+	Address   solana.PublicKey `json:"address"`
+	Imported  bool             `json:"imported"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+func NewWalletResponse(w *account.Wallet) *WalletResponse {
+	return &WalletResponse{
+		Index:     w.Index,
+		Label:     w.Label,
+		Address:   w.PublicKey,
+		Imported:  w.Imported,
+		CreatedAt: w.CreatedAt,
+	}
+}
+
+func WalletsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		sub, ok := request.(string)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		wallets, err := svc.Wallets(ctx, sub)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := make([]*WalletResponse, len(wallets))
+		for i, w := range wallets {
+			resp[i] = NewWalletResponse(w)
+		}
+
+		return resp, nil
+	}
+}
+
+// AccountResponse is the admin view of an account; it never carries key material.
+type AccountResponse struct {
+	Subject    string            `json:"subject"`
+	KeyVersion int               `json:"key_version"`
+	Wallets    []*WalletResponse `json:"wallets"`
+	Frozen     *FreezeResponse   `json:"frozen,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+type FreezeResponse struct {
+	Reason string    `json:"reason"`
+	By     string    `json:"by"`
+	At     time.Time `json:"at"`
+}
+
+func NewAccountResponse(a *account.Account) *AccountResponse {
+	wallets := make([]*WalletResponse, len(a.Wallets))
+	for i, w := range a.Wallets {
+		wallets[i] = NewWalletResponse(w)
+	}
+
+	resp := &AccountResponse{
+		Subject:    a.Subject,
+		KeyVersion: a.KeyVersion,
+		Wallets:    wallets,
+		CreatedAt:  a.CreatedAt,
+		UpdatedAt:  a.UpdatedAt,
+	}
+
+	if a.Frozen != nil {
+		resp.Frozen = &FreezeResponse{
+			Reason: a.Frozen.Reason,
+			By:     a.Frozen.By,
+			At:     a.Frozen.At,
+		}
+	}
+
+	return resp
+}
+
+func AccountEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		sub, ok := request.(string)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		a, err := svc.Account(ctx, sub)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewAccountResponse(a), nil
+	}
+}
+
+// FinalizeRequest carries a passkey assertion together with the subject of
+// the route it was posted to.
+type FinalizeRequest struct {
+	Subject   string
+	Assertion *protocol.ParsedCredentialAssertionData
+}
+
+type InitializeDeleteAccountRequest struct {
+	Subject       string `json:"-"`
+	UserID        string `json:"user_id"`
+	TransactionID string `json:"transaction_id"`
+}
+
+func InitializeDeleteAccountEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(*InitializeDeleteAccountRequest)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		opts, mediation, err := svc.InitializeDeleteAccount(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := &passkeys.InitializeLoginResponse{
+			Response:  opts.Response,
+			Mediation: mediation,
+		}
+
+		return resp, err
+	}
+}
+
+type FinalizeDeleteAccountResponse struct {
+	Subject string `json:"subject"`
+}
+
+func FinalizeDeleteAccountEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(*FinalizeRequest)
+		if !ok {
+			return nil, errors.New("invalid type")
+		}
+
+		subject, err := svc.FinalizeDeleteAccount(ctx, req.Subject, req.Assertion)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := &FinalizeDeleteAccountResponse{
+			Subject: subject,
+		}
+
+		return resp, nil
+	}
+}
+
+type InitializeExportKeyRequest struct {
+	Subject       string       `json:"-"`
+	UserID        string       `json:"user_id"`
+	TransactionID string       `json:"transaction_id"`
+	Wallet        int          `json:"wallet"`
+	Format        ExportFormat `json:"format"`
+}
+
+func InitializeExportKeyEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(*InitializeExportKeyRequest)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		opts, mediation, err := svc.InitializeExportKey(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := &passkeys.InitializeLoginResponse{
+			Response:  opts.Response,
+			Mediation: mediation,
+		}
+
+		return resp, err
+	}
+}
+
+type FinalizeExportKeyRequest struct {
+	Subject    string
+	Format     ExportFormat
+	Assertion  *protocol.ParsedCredentialAssertionData
+	Passphrase []byte
+}
+
+func FinalizeExportKeyEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(*FinalizeExportKeyRequest)
+		if !ok {
+			return nil, errors.New("invalid type")
+		}
+
+		return svc.FinalizeExportKey(ctx, req)
+	}
+}
+
+type FreezeAccountRequest struct {
+	Subject string `json:"-"`
+	By      string `json:"-"`
+	Reason  string `json:"reason"`
+}
+
+func FreezeAccountEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(*FreezeAccountRequest)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		a, err := svc.FreezeAccount(ctx, req.Subject, req.By, req.Reason)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewAccountResponse(a), nil
+	}
+}
+
+type InitializeUnfreezeAccountRequest struct {
+	Subject       string `json:"-"`
+	By            string `json:"-"`
+	UserID        string `json:"user_id"`
+	TransactionID string `json:"transaction_id"`
+}
+
+func InitializeUnfreezeAccountEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(*InitializeUnfreezeAccountRequest)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		opts, mediation, err := svc.InitializeUnfreezeAccount(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := &passkeys.InitializeLoginResponse{
+			Response:  opts.Response,
+			Mediation: mediation,
+		}
+
+		return resp, err
+	}
+}
+
+type FinalizeUnfreezeAccountRequest struct {
+	Subject   string
+	By        string
+	Assertion *protocol.ParsedCredentialAssertionData
+}
+
+func FinalizeUnfreezeAccountEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(*FinalizeUnfreezeAccountRequest)
+		if !ok {
+			return nil, errors.New("invalid type")
+		}
+
+		a, err := svc.FinalizeUnfreezeAccount(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewAccountResponse(a), nil
+	}
+}
+
+type ListAccountsRequest struct {
+	CreatedAfter  time.Time `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore time.Time `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	KeyVersion    int       `form:"key_version"`
+	Cursor        string    `form:"cursor"`
+	Limit         int       `form:"limit"`
+}
+
+type ListAccountsResponse struct {
+	Accounts []*AccountResponse `json:"accounts"`
+	Next     string             `json:"next,omitempty"`
+}
+
+func ListAccountsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(*ListAccountsRequest)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		accounts, next, err := svc.ListAccounts(ctx, account.ListFilter{
+			CreatedAfter:  req.CreatedAfter,
+			CreatedBefore: req.CreatedBefore,
+			KeyVersion:    req.KeyVersion,
+			Cursor:        req.Cursor,
+			Limit:         req.Limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		resp := &ListAccountsResponse{
+			Accounts: make([]*AccountResponse, len(accounts)),
+			Next:     next,
+		}
+
+		for i, a := range accounts {
+			resp.Accounts[i] = NewAccountResponse(a)
+		}
+
+		return resp, nil
+	}
+}
+
+type CreateWalletRequest struct {
+	Subject string `json:"-"`
+	Label   string `json:"label"`
+}
+
+func CreateWalletEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(*CreateWalletRequest)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		w, err := svc.CreateWallet(ctx, req.Subject, req.Label)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewWalletResponse(w), nil
+	}
+}
+
+func CreateImportChallengeEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		sub, ok := request.(string)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		return svc.CreateImportChallenge(ctx, sub)
+	}
+}
+
+type ImportWalletRequest struct {
+	Subject     string           `json:"-"`
+	ChallengeID string           `json:"challenge_id"`
+	SecretKey   json.RawMessage  `json:"secret_key"`
+	Signature   solana.Signature `json:"signature"`
+	Label       string           `json:"label"`
+}
+
+func ImportWalletEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(*ImportWalletRequest)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		w, err := svc.ImportWallet(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewWalletResponse(w), nil
+	}
+}
+
+type RenameWalletRequest struct {
+	Subject string `json:"-"`
+	Index   int    `json:"-"`
+	Label   string `json:"label"`
+}
+
+func RenameWalletEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(*RenameWalletRequest)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		w, err := svc.RenameWallet(ctx, req.Subject, req.Index, req.Label)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewWalletResponse(w), nil
 	}
 }
 
 type SignMessageRequest struct {
 	Subject string `json:"-"`
+	Wallet  int    `json:"wallet"`
 	Message []byte `json:"message"`
 }
 
@@ -35,7 +436,7 @@ func SignMessageEndpoint(svc Service) endpoint.Endpoint {
 			return nil, errors.New("invalid request")
 		}
 
-		return svc.SignMessage(req.Subject, req.Message)
+		return svc.SignMessage(ctx, req.Subject, req.Wallet, req.Message)
 	}
 }
 
@@ -43,6 +444,7 @@ type InitializeSignMessageRequest struct {
 	Subject       string `json:"-"`
 	UserID        string `json:"user_id"`
 	TransactionID string `json:"transaction_id"`
+	Wallet        int    `json:"wallet"`
 	Message       []byte `json:"message"`
 }
 
@@ -53,7 +455,7 @@ func InitializeSignMessageEndpoint(svc Service) endpoint.Endpoint {
 			return nil, errors.New("invalid request")
 		}
 
-		opts, mediation, err := svc.InitializeSignMessage(req)
+		opts, mediation, err := svc.InitializeSignMessage(ctx, req)
 		if err != nil {
 			return nil, err
 		}
@@ -67,18 +469,148 @@ func InitializeSignMessageEndpoint(svc Service) endpoint.Endpoint {
 	}
 }
 
+type InitializeSignOffchainMessageRequest struct {
+	Subject           string           `json:"-"`
+	UserID            string           `json:"user_id"`
+	TransactionID     string           `json:"transaction_id"`
+	Wallet            int              `json:"wallet"`
+	ApplicationDomain solana.PublicKey `json:"application_domain"`
+	Message           string           `json:"message"`
+}
+
+type InitializeSignOffchainMessageResponse struct {
+	passkeys.InitializeLoginResponse
+	Format  string `json:"format"`
+	Message []byte `json:"message"`
+}
+
+func InitializeSignOffchainMessageEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(*InitializeSignOffchainMessageRequest)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		opts, mediation, message, err := svc.InitializeSignOffchainMessage(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		bs, err := message.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+
+		resp := &InitializeSignOffchainMessageResponse{
+			InitializeLoginResponse: passkeys.InitializeLoginResponse{
+				Response:  opts.Response,
+				Mediation: mediation,
+			},
+			Format:  message.Format.String(),
+			Message: bs,
+		}
+
+		return resp, nil
+	}
+}
+
+type InitializeSignInRequest struct {
+	Subject        string    `json:"-"`
+	UserID         string    `json:"user_id"`
+	TransactionID  string    `json:"transaction_id"`
+	Wallet         int       `json:"wallet"`
+	Domain         string    `json:"domain"`
+	Statement      string    `json:"statement"`
+	URI            string    `json:"uri"`
+	Version        string    `json:"version"`
+	ChainID        string    `json:"chain_id"`
+	Nonce          string    `json:"nonce"`
+	IssuedAt       time.Time `json:"issued_at"`
+	ExpirationTime time.Time `json:"expiration_time"`
+	NotBefore      time.Time `json:"not_before"`
+	RequestID      string    `json:"request_id"`
+	Resources      []string  `json:"resources"`
+}
+
+type InitializeSignInResponse struct {
+	passkeys.InitializeLoginResponse
+	Input   *siws.Message `json:"input"`
+	Message string        `json:"message"`
+}
+
+func InitializeSignInEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(*InitializeSignInRequest)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		opts, mediation, message, err := svc.InitializeSignIn(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := &InitializeSignInResponse{
+			InitializeLoginResponse: passkeys.InitializeLoginResponse{
+				Response:  opts.Response,
+				Mediation: mediation,
+			},
+			Input:   message,
+			Message: message.String(),
+		}
+
+		return resp, nil
+	}
+}
+
+type VerifySignInRequest struct {
+	Subject   string           `json:"-"`
+	Message   string           `json:"message"`
+	Signature solana.Signature `json:"signature"`
+	Domain    string           `json:"domain"`
+	Nonce     string           `json:"nonce"`
+}
+
+type VerifySignInResponse struct {
+	Valid   bool          `json:"valid"`
+	Wallet  int           `json:"wallet"`
+	Message *siws.Message `json:"message"`
+}
+
+func VerifySignInEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(*VerifySignInRequest)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		message, index, err := svc.VerifySignIn(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := &VerifySignInResponse{
+			Valid:   true,
+			Wallet:  index,
+			Message: message,
+		}
+
+		return resp, nil
+	}
+}
+
 type FinalizeSignMessageResponse struct {
 	Signature solana.Signature `json:"signature"`
 }
 
 func FinalizeSignMessageEndpoint(svc Service) endpoint.Endpoint {
 	return func(ctx context.Context, request any) (any, error) {
-		req, ok := request.(*protocol.ParsedCredentialAssertionData)
+		req, ok := request.(*FinalizeRequest)
 		if !ok {
 			return nil, errors.New("invalid type")
 		}
 
-		sig, err := svc.FinalizeSignMessage(req)
+		sig, err := svc.FinalizeSignMessage(ctx, req.Subject, req.Assertion)
 		if err != nil {
 			return nil, err
 		}
@@ -93,12 +625,14 @@ func FinalizeSignMessageEndpoint(svc Service) endpoint.Endpoint {
 
 type SignTransactionRequest struct {
 	Subject     string
+	Wallet      int
 	Transaction *solana.Transaction
 }
 
 func (req *SignTransactionRequest) UnmarshalJSON(data []byte) error {
 	var raw struct {
 		Subject     string `json:"-"`
+		Wallet      int    `json:"wallet"`
 		Transaction []byte `json:"transaction"`
 	}
 
@@ -106,6 +640,8 @@ func (req *SignTransactionRequest) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	req.Wallet = raw.Wallet
+
 	transaction, err := solana.TransactionFromBytes(raw.Transaction)
 	if err != nil {
 		return err
@@ -145,7 +681,7 @@ func SignTransactionEndpoint(svc Service) endpoint.Endpoint {
 			return nil, errors.New("invalid request")
 		}
 
-		sigs, err := svc.SignTransaction(req.Subject, req.Transaction)
+		sigs, err := svc.SignTransaction(ctx, req.Subject, req.Wallet, req.Transaction)
 		if err != nil {
 			return nil, err
 		}
@@ -163,8 +699,10 @@ type InitializeSignTransactionRequest struct {
 	Subject       string
 	UserID        string
 	TransactionID string
+	Wallet        int
 	Transaction   *solana.Transaction
 	Versioned     bool
+	Sponsored     bool
 }
 
 func (req *InitializeSignTransactionRequest) UnmarshalJSON(data []byte) error {
@@ -172,8 +710,10 @@ func (req *InitializeSignTransactionRequest) UnmarshalJSON(data []byte) error {
 		Subject       string `json:"-"`
 		UserID        string `json:"user_id"`
 		TransactionID string `json:"transaction_id"`
+		Wallet        int    `json:"wallet"`
 		Transaction   []byte `json:"transaction"`
 		Versioned     bool   `json:"versioned"`
+		Sponsored     bool   `json:"sponsored"`
 	}
 
 	if err := json.Unmarshal(data, &raw); err != nil {
@@ -182,6 +722,7 @@ func (req *InitializeSignTransactionRequest) UnmarshalJSON(data []byte) error {
 
 	req.UserID = raw.UserID
 	req.TransactionID = raw.TransactionID
+	req.Wallet = raw.Wallet
 
 	transaction, err := solana.TransactionFromBytes(raw.Transaction)
 	if err != nil {
@@ -190,6 +731,7 @@ func (req *InitializeSignTransactionRequest) UnmarshalJSON(data []byte) error {
 
 	req.Transaction = transaction
 	req.Versioned = raw.Versioned
+	req.Sponsored = raw.Sponsored
 
 	return nil
 }
@@ -201,20 +743,32 @@ func InitializeSignTransactionEndpoint(svc Service) endpoint.Endpoint {
 			return nil, errors.New("invalid request")
 		}
 
-		opts, mediation, err := svc.InitializeSignTransaction(req)
+		opts, mediation, preview, err := svc.InitializeSignTransaction(ctx, req)
 		if err != nil {
 			return nil, err
 		}
 
-		resp := &passkeys.InitializeLoginResponse{
-			Response:  opts.Response,
-			Mediation: mediation,
+		resp := &InitializeSignTransactionResponse{
+			InitializeLoginResponse: passkeys.InitializeLoginResponse{
+				Response:  opts.Response,
+				Mediation: mediation,
+			},
+			Summary:    preview.Summary,
+			Simulation: preview.Simulation,
 		}
 
 		return resp, err
 	}
 }
 
+// InitializeSignTransactionResponse extends the passkey options with a
+// decoded preview of what the user is approving.
+type InitializeSignTransactionResponse struct {
+	passkeys.InitializeLoginResponse
+	Summary    *inspect.Summary `json:"summary"`
+	Simulation *Simulation      `json:"simulation,omitempty"`
+}
+
 type FinalizeSignTransactionResponse struct {
 	Transaction *solana.Transaction
 	Versioned   bool
@@ -242,12 +796,12 @@ func (resp *FinalizeSignTransactionResponse) MarshalJSON() ([]byte, error) {
 
 func FinalizeSignTransactionEndpoint(svc Service) endpoint.Endpoint {
 	return func(ctx context.Context, request any) (any, error) {
-		req, ok := request.(*protocol.ParsedCredentialAssertionData)
+		req, ok := request.(*FinalizeRequest)
 		if !ok {
 			return nil, errors.New("invalid type")
 		}
 
-		transaction, versioned, err := svc.FinalizeSignTransaction(req)
+		transaction, versioned, err := svc.FinalizeSignTransaction(ctx, req.Subject, req.Assertion)
 		if err != nil {
 			return nil, err
 		}
@@ -262,6 +816,288 @@ func FinalizeSignTransactionEndpoint(svc Service) endpoint.Endpoint {
 	}
 }
 
+type FinalizeSubmitTransactionResponse struct {
+	*FinalizeSignTransactionResponse
+	Status <-chan *SubmitStatus
+}
+
+func FinalizeSubmitTransactionEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(*FinalizeRequest)
+		if !ok {
+			return nil, errors.New("invalid type")
+		}
+
+		transaction, versioned, ch, err := svc.FinalizeSubmitTransaction(ctx, req.Subject, req.Assertion)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := &FinalizeSubmitTransactionResponse{
+			FinalizeSignTransactionResponse: &FinalizeSignTransactionResponse{
+				Transaction: transaction,
+				Versioned:   versioned,
+				Signatures:  transaction.Signatures,
+			},
+			Status: ch,
+		}
+
+		return resp, nil
+	}
+}
+
+type InitializeSignTransactionsRequest struct {
+	Subject       string
+	UserID        string
+	TransactionID string
+	Wallet        int
+	Transactions  []*BatchTransaction
+}
+
+func (req *InitializeSignTransactionsRequest) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		UserID        string `json:"user_id"`
+		TransactionID string `json:"transaction_id"`
+		Wallet        int    `json:"wallet"`
+		Transactions  []struct {
+			Transaction []byte `json:"transaction"`
+			Versioned   bool   `json:"versioned"`
+		} `json:"transactions"`
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	req.UserID = raw.UserID
+	req.TransactionID = raw.TransactionID
+	req.Wallet = raw.Wallet
+	req.Transactions = make([]*BatchTransaction, len(raw.Transactions))
+
+	for i, item := range raw.Transactions {
+		transaction, err := solana.TransactionFromBytes(item.Transaction)
+		if err != nil {
+			return fmt.Errorf("transaction %d: %w", i, err)
+		}
+
+		req.Transactions[i] = &BatchTransaction{
+			Transaction: transaction,
+			Versioned:   item.Versioned,
+		}
+	}
+
+	return nil
+}
+
+type InitializeSignTransactionsResponse struct {
+	passkeys.InitializeLoginResponse
+	Previews []*TransactionPreview `json:"previews"`
+}
+
+func InitializeSignTransactionsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(*InitializeSignTransactionsRequest)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		opts, mediation, previews, err := svc.InitializeSignTransactions(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := &InitializeSignTransactionsResponse{
+			InitializeLoginResponse: passkeys.InitializeLoginResponse{
+				Response:  opts.Response,
+				Mediation: mediation,
+			},
+			Previews: previews,
+		}
+
+		return resp, nil
+	}
+}
+
+func (result *BatchSignResult) MarshalJSON() ([]byte, error) {
+	bs, err := result.Transaction.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	out := struct {
+		Index       int                `json:"index"`
+		Transaction []byte             `json:"transaction"`
+		Versioned   bool               `json:"versioned"`
+		Signatures  []solana.Signature `json:"signatures,omitempty"`
+		Error       string             `json:"error,omitempty"`
+	}{
+		Index:       result.Index,
+		Transaction: bs,
+		Versioned:   result.Versioned,
+		Signatures:  result.Signatures,
+	}
+
+	if result.Err != nil {
+		out.Error = result.Err.Error()
+	}
+
+	return json.Marshal(out)
+}
+
+type FinalizeSignTransactionsResponse struct {
+	Results []*BatchSignResult `json:"results"`
+	Failed  int                `json:"failed"`
+}
+
+func FinalizeSignTransactionsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(*FinalizeRequest)
+		if !ok {
+			return nil, errors.New("invalid type")
+		}
+
+		results, err := svc.FinalizeSignTransactions(ctx, req.Subject, req.Assertion)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := &FinalizeSignTransactionsResponse{
+			Results: results,
+		}
+
+		for _, result := range results {
+			if result.Err != nil {
+				resp.Failed++
+			}
+		}
+
+		return resp, nil
+	}
+}
+
+type VerifyRequest struct {
+	Subject     string             `json:"-"`
+	PublicKey   *solana.PublicKey  `json:"public_key"`
+	Message     []byte             `json:"message"`
+	Transaction []byte             `json:"transaction"`
+	Signature   *solana.Signature  `json:"signature"`
+	Signatures  []solana.Signature `json:"signatures"`
+}
+
+func VerifyEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(*VerifyRequest)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		return svc.Verify(ctx, req)
+	}
+}
+
+func SponsorEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		subject, ok := request.(string)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		return svc.Sponsor(ctx, subject)
+	}
+}
+
+type PortfolioRequest struct {
+	Subject string `form:"-"`
+	Wallet  int    `form:"wallet"`
+}
+
+func PortfolioEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(*PortfolioRequest)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		return svc.Portfolio(ctx, req.Subject, req.Wallet)
+	}
+}
+
+type BuildTransferRequest struct {
+	Subject   string            `json:"-"`
+	Wallet    int               `json:"wallet"`
+	To        solana.PublicKey  `json:"to"`
+	Mint      *solana.PublicKey `json:"mint"`
+	Amount    uint64            `json:"amount"`
+	Memo      string            `json:"memo"`
+	Sponsored bool              `json:"sponsored"`
+}
+
+func BuildTransferEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(*BuildTransferRequest)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		built, err := svc.BuildTransfer(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return &BuildTransactionResponse{built}, nil
+	}
+}
+
+type BuildMemoRequest struct {
+	Subject   string `json:"-"`
+	Wallet    int    `json:"wallet"`
+	Memo      string `json:"memo"`
+	Sponsored bool   `json:"sponsored"`
+}
+
+func BuildMemoEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(*BuildMemoRequest)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		built, err := svc.BuildMemo(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return &BuildTransactionResponse{built}, nil
+	}
+}
+
+// BuildTransactionResponse serializes the transaction the way
+// InitializeSignTransactionRequest expects it.
+type BuildTransactionResponse struct {
+	*BuiltTransaction
+}
+
+func (resp *BuildTransactionResponse) MarshalJSON() ([]byte, error) {
+	bs, err := resp.Transaction.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	out := struct {
+		Transaction          []byte           `json:"transaction"`
+		Versioned            bool             `json:"versioned"`
+		FeePayer             solana.PublicKey `json:"fee_payer"`
+		LastValidBlockHeight uint64           `json:"last_valid_block_height"`
+	}{
+		Transaction:          bs,
+		Versioned:            false,
+		FeePayer:             resp.FeePayer,
+		LastValidBlockHeight: resp.LastValidBlockHeight,
+	}
+
+	return json.Marshal(out)
+}
+
 type CreateSessionRequest struct {
 	Data []byte `json:"data"`
 }
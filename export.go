@@ -0,0 +1,286 @@
+package wallet
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/go-webauthn/webauthn/protocol"
+
+	"github.com/flarexio/identity/passkeys"
+	"github.com/flarexio/wallet/account"
+	"github.com/flarexio/wallet/keys"
+)
+
+var (
+	ErrExportRateLimited    = errors.New("export rate limit exceeded")
+	ErrInvalidExportRequest = errors.New("invalid export request")
+)
+
+const (
+	exportLimit  = 3
+	exportWindow = 24 * time.Hour
+
+	minKeystorePassphrase = 8
+)
+
+type ExportFormat string
+
+const (
+	// ExportFormatKeygen is the JSON byte array written by solana-keygen.
+	ExportFormatKeygen   ExportFormat = "keygen"
+	ExportFormatKeystore ExportFormat = "keystore"
+)
+
+func (f ExportFormat) Valid() bool {
+	switch f {
+	case ExportFormatKeygen, ExportFormatKeystore:
+		return true
+	default:
+		return false
+	}
+}
+
+// Keypair marshals as a JSON array of numbers rather than base64, so that
+// it can be read by solana-keygen and wallets such as Phantom.
+type Keypair []byte
+
+func (k Keypair) MarshalJSON() ([]byte, error) {
+	arr := make([]int, len(k))
+	for i, b := range k {
+		arr[i] = int(b)
+	}
+
+	return json.Marshal(arr)
+}
+
+func (k *Keypair) UnmarshalJSON(data []byte) error {
+	var arr []int
+	if err := json.Unmarshal(data, &arr); err != nil {
+		return err
+	}
+
+	bs := make([]byte, len(arr))
+	for i, v := range arr {
+		if v < 0 || v > 255 {
+			return errors.New("invalid keypair")
+		}
+
+		bs[i] = byte(v)
+	}
+
+	*k = bs
+	return nil
+}
+
+// Keystore is a private key encrypted under a user-chosen passphrase. The
+// address is bound to the ciphertext as additional data.
+type Keystore struct {
+	Version int                 `json:"version"`
+	Address solana.PublicKey    `json:"address"`
+	Crypto  *keys.EncryptedData `json:"crypto"`
+}
+
+func NewKeystore(privkey solana.PrivateKey, passphrase []byte) (*Keystore, error) {
+	if len(passphrase) < minKeystorePassphrase {
+		return nil, errors.New("passphrase is too short")
+	}
+
+	address := privkey.PublicKey()
+
+	data, err := keys.EncryptWithPassphrase(passphrase, privkey, address.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Keystore{
+		Version: 1,
+		Address: address,
+		Crypto:  data,
+	}, nil
+}
+
+func (ks *Keystore) Decrypt(passphrase []byte) (solana.PrivateKey, error) {
+	plaintext, err := ks.Crypto.Decrypt(passphrase, ks.Address.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	privkey := solana.PrivateKey(plaintext)
+	if !privkey.PublicKey().Equals(ks.Address) {
+		return nil, errors.New("address mismatch")
+	}
+
+	return privkey, nil
+}
+
+type ExportedKey struct {
+	Format   ExportFormat     `json:"format"`
+	Address  solana.PublicKey `json:"address"`
+	Keypair  Keypair          `json:"keypair,omitempty"`
+	Keystore *Keystore        `json:"keystore,omitempty"`
+}
+
+// checkExport validates the format and passphrase up front, so that a bad
+// request never consumes a passkey ceremony.
+func checkExport(format ExportFormat, passphrase []byte) error {
+	if !format.Valid() {
+		return fmt.Errorf("%w: unknown format %q", ErrInvalidExportRequest, format)
+	}
+
+	if format == ExportFormatKeystore && len(passphrase) < minKeystorePassphrase {
+		return fmt.Errorf("%w: passphrase must be at least %d bytes", ErrInvalidExportRequest, minKeystorePassphrase)
+	}
+
+	return nil
+}
+
+// checkExportLimit counts the exports recorded in the audit log within the
+// rate limit window.
+func (svc *service) checkExportLimit(subject string) error {
+	events, err := svc.accounts.AuditEvents(subject)
+	if err != nil {
+		return err
+	}
+
+	since := time.Now().Add(-exportWindow)
+
+	var count int
+	for _, e := range events {
+		if e.Action == account.AuditKeyExported && e.Time.After(since) {
+			count++
+		}
+	}
+
+	if count >= exportLimit {
+		return ErrExportRateLimited
+	}
+
+	return nil
+}
+
+func (svc *service) InitializeExportKey(ctx context.Context, req *InitializeExportKeyRequest) (*protocol.CredentialAssertion, string, error) {
+	if !req.Format.Valid() {
+		return nil, "", fmt.Errorf("%w: unknown format %q", ErrInvalidExportRequest, req.Format)
+	}
+
+	a, err := svc.findActive(req.Subject)
+	if err != nil {
+		return nil, "", err
+	}
+
+	w, err := a.FindWallet(req.Wallet)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := svc.checkExportLimit(req.Subject); err != nil {
+		return nil, "", err
+	}
+
+	data := "wallet:export:" + w.PublicKey.String() + ":" + string(req.Format)
+
+	r := &passkeys.InitializeTransactionRequest{
+		UserID:          req.UserID,
+		TransactionID:   req.TransactionID,
+		TransactionData: sha256.Sum256([]byte(data)),
+	}
+
+	opts, mediation, err := svc.passkeys.InitializeTransaction(r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	t, err := account.NewExportKeyTransaction(req.TransactionID, req.Subject, req.Wallet, string(req.Format))
+	if err != nil {
+		return nil, "", err
+	}
+
+	t.UserID = req.UserID
+
+	if err := svc.accounts.CacheTransaction(t, 120*time.Second); err != nil {
+		return nil, "", err
+	}
+
+	return opts, mediation, nil
+}
+
+// FinalizeExportKey exports in the format the caller declares, which must be
+// the one approved at initialization.
+func (svc *service) FinalizeExportKey(ctx context.Context, req *FinalizeExportKeyRequest) (*ExportedKey, error) {
+	if err := checkExport(req.Format, req.Passphrase); err != nil {
+		return nil, err
+	}
+
+	t, err := svc.finalize(req.Subject, req.Assertion)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Export == nil {
+		return nil, errors.New("invalid transaction")
+	}
+
+	if ExportFormat(t.Export.Format) != req.Format {
+		return nil, fmt.Errorf("%w: approved format is %s", ErrInvalidExportRequest, t.Export.Format)
+	}
+
+	return svc.exportKey(ctx, t.Subject, t.Export.Wallet, req.Format, req.Passphrase)
+}
+
+func (svc *service) exportKey(ctx context.Context, subject string, index int, format ExportFormat, passphrase []byte) (*ExportedKey, error) {
+	svc.accountsLock.Lock()
+	defer svc.accountsLock.Unlock()
+
+	// concurrent ceremonies must not slip past the limit
+	if err := svc.checkExportLimit(subject); err != nil {
+		return nil, err
+	}
+
+	a, err := svc.findActive(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	privkey, err := svc.privateKey(ctx, a, index)
+	if err != nil {
+		return nil, err
+	}
+
+	exported := &ExportedKey{
+		Format:  format,
+		Address: privkey.PublicKey(),
+	}
+
+	switch format {
+	case ExportFormatKeygen:
+		exported.Keypair = Keypair(privkey)
+
+	case ExportFormatKeystore:
+		ks, err := NewKeystore(privkey, passphrase)
+		if err != nil {
+			return nil, err
+		}
+
+		exported.Keystore = ks
+
+	default:
+		return nil, errors.New("invalid export format")
+	}
+
+	e := account.NewAuditEvent(subject, account.AuditKeyExported)
+	e.Details["wallet"] = strconv.Itoa(index)
+	e.Details["address"] = exported.Address.String()
+	e.Details["format"] = string(format)
+
+	if err := svc.accounts.RecordAudit(e); err != nil {
+		return nil, err
+	}
+
+	return exported, nil
+}
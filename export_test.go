@@ -0,0 +1,168 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportKey(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	wallet, err := svc.Wallet(ctx, "user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	exported, err := svc.exportKey(ctx, "user", 0, ExportFormatKeygen, nil)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	bs, err := json.Marshal(exported.Keypair)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	var keypair Keypair
+	if err := json.Unmarshal(bs, &keypair); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(byte('['), bs[0])
+	assert.Len(keypair, 64)
+	assert.Equal(wallet, exported.Address)
+
+	_, err = svc.exportKey(ctx, "user", 0, ExportFormatKeystore, []byte("short"))
+	assert.Error(err)
+
+	passphrase := []byte("correct horse battery")
+
+	exported, err = svc.exportKey(ctx, "user", 0, ExportFormatKeystore, passphrase)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	privkey, err := exported.Keystore.Decrypt(passphrase)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(wallet, privkey.PublicKey())
+
+	_, err = exported.Keystore.Decrypt([]byte("wrong passphrase"))
+	assert.Error(err)
+
+	_, err = svc.exportKey(ctx, "user", 0, ExportFormatKeygen, nil)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	_, err = svc.exportKey(ctx, "user", 0, ExportFormatKeygen, nil)
+	assert.ErrorIs(err, ErrExportRateLimited)
+}
+
+func TestFinalizeExportKey(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc, p := newTestServiceWithPasskeys(t)
+
+	wallet, err := svc.Wallet(ctx, "user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	opts, _, err := svc.InitializeExportKey(ctx, &InitializeExportKeyRequest{
+		Subject:       "user",
+		UserID:        "user-id",
+		TransactionID: uuid.New().String(),
+		Format:        ExportFormatKeystore,
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	req := &FinalizeExportKeyRequest{
+		Subject:    "user",
+		Format:     ExportFormatKeystore,
+		Assertion:  p.assert(opts),
+		Passphrase: []byte("short"),
+	}
+
+	// a bad request is refused before the ceremony is consumed
+	_, err = svc.FinalizeExportKey(ctx, req)
+	assert.ErrorIs(err, ErrInvalidExportRequest)
+
+	req.Format = "pem"
+	req.Passphrase = []byte("correct horse battery")
+
+	_, err = svc.FinalizeExportKey(ctx, req)
+	assert.ErrorIs(err, ErrInvalidExportRequest)
+	assert.Equal(0, p.finalized)
+
+	req.Format = ExportFormatKeystore
+
+	exported, err := svc.FinalizeExportKey(ctx, req)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	privkey, err := exported.Keystore.Decrypt(req.Passphrase)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(wallet, privkey.PublicKey())
+	assert.Equal(1, p.finalized)
+}
+
+func TestFinalizeExportKeyFormatMismatch(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc, p := newTestServiceWithPasskeys(t)
+
+	if _, err := svc.Wallet(ctx, "user"); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	opts, _, err := svc.InitializeExportKey(ctx, &InitializeExportKeyRequest{
+		Subject:       "user",
+		UserID:        "user-id",
+		TransactionID: uuid.New().String(),
+		Format:        ExportFormatKeystore,
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	_, err = svc.FinalizeExportKey(ctx, &FinalizeExportKeyRequest{
+		Subject:   "user",
+		Format:    ExportFormatKeygen,
+		Assertion: p.assert(opts),
+	})
+	assert.ErrorIs(err, ErrInvalidExportRequest)
+}
@@ -0,0 +1,441 @@
+package wallet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flarexio/identity/passkeys"
+	"github.com/flarexio/wallet/account"
+)
+
+// unknownAssertion answers a ceremony the passkeys service never started.
+func unknownAssertion() *protocol.ParsedCredentialAssertionData {
+	var req protocol.ParsedCredentialAssertionData
+	req.Response.CollectedClientData.Challenge = "unknown"
+	return &req
+}
+
+func TestFinalizeSignMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc, p := newTestServiceWithPasskeys(t)
+
+	wallet, err := svc.Wallet(ctx, "user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	opts, _, err := svc.InitializeSignMessage(ctx, &InitializeSignMessageRequest{
+		Subject:       "user",
+		UserID:        "user-id",
+		TransactionID: uuid.New().String(),
+		Message:       []byte("hello"),
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	// nothing is signed until the ceremony succeeds
+	_, err = svc.FinalizeSignMessage(ctx, "user", unknownAssertion())
+	assert.Error(err)
+	assert.Equal(0, p.finalized)
+
+	sig, err := svc.FinalizeSignMessage(ctx, "user", p.assert(opts))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.True(sig.Verify(wallet, []byte("hello")))
+}
+
+func TestFinalizeSignMessageTampered(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc, p := newTestServiceWithPasskeys(t)
+
+	if _, err := svc.Wallet(ctx, "user"); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	id := uuid.New().String()
+
+	opts, _, err := svc.InitializeSignMessage(ctx, &InitializeSignMessageRequest{
+		Subject:       "user",
+		UserID:        "user-id",
+		TransactionID: id,
+		Message:       []byte("hello"),
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	// the cached payload is swapped after approval
+	tampered, err := account.NewSignMessageTransaction(id, "user", 0, []byte("hello"))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	tampered.UserID = "user-id"
+	tampered.Message.Message = []byte("goodbye")
+
+	if err := svc.accounts.CacheTransaction(tampered, time.Minute); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	_, err = svc.FinalizeSignMessage(ctx, "user", p.assert(opts))
+	assert.ErrorIs(err, account.ErrPayloadMismatch)
+}
+
+func TestFinalizeSignTransaction(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc, p := newTestServiceWithPasskeys(t)
+
+	wallet, err := svc.Wallet(ctx, "user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	tx := memoTransaction(t, wallet, wallet)
+
+	opts, _, _, err := svc.InitializeSignTransaction(ctx, &InitializeSignTransactionRequest{
+		Subject:       "user",
+		UserID:        "user-id",
+		TransactionID: uuid.New().String(),
+		Transaction:   tx,
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	_, _, err = svc.FinalizeSignTransaction(ctx, "user", unknownAssertion())
+	assert.Error(err)
+	assert.Equal(0, p.finalized)
+
+	signed, _, err := svc.FinalizeSignTransaction(ctx, "user", p.assert(opts))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if err := signed.VerifySignatures(); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+}
+
+func TestFinalizeSignTransactionTampered(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc, p := newTestServiceWithPasskeys(t)
+
+	wallet, err := svc.Wallet(ctx, "user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	id := uuid.New().String()
+	tx := memoTransaction(t, wallet, wallet)
+
+	opts, _, _, err := svc.InitializeSignTransaction(ctx, &InitializeSignTransactionRequest{
+		Subject:       "user",
+		UserID:        "user-id",
+		TransactionID: id,
+		Transaction:   tx,
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	tampered, err := account.NewSignTransaction(id, "user", 0, tx, false)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	tampered.UserID = "user-id"
+	tampered.Transaction.Transaction = memoTransaction(t, solana.NewWallet().PublicKey(), wallet)
+
+	if err := svc.accounts.CacheTransaction(tampered, time.Minute); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	_, _, err = svc.FinalizeSignTransaction(ctx, "user", p.assert(opts))
+	assert.ErrorIs(err, account.ErrPayloadMismatch)
+}
+
+func TestFinalizeSignTransactions(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc, p := newTestServiceWithPasskeys(t)
+
+	wallet, err := svc.Wallet(ctx, "user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	id := uuid.New().String()
+	txs := []*solana.Transaction{
+		memoTransaction(t, wallet, wallet),
+		memoTransaction(t, wallet, wallet),
+	}
+
+	opts, _, _, err := svc.InitializeSignTransactions(ctx, &InitializeSignTransactionsRequest{
+		Subject:       "user",
+		UserID:        "user-id",
+		TransactionID: id,
+		Transactions: []*BatchTransaction{
+			{Transaction: txs[0]},
+			{Transaction: txs[1]},
+		},
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	_, err = svc.FinalizeSignTransactions(ctx, "user", unknownAssertion())
+	assert.Error(err)
+	assert.Equal(0, p.finalized)
+
+	results, err := svc.FinalizeSignTransactions(ctx, "user", p.assert(opts))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if !assert.Len(results, 2) {
+		return
+	}
+
+	for _, result := range results {
+		if !assert.NoError(result.Err) {
+			continue
+		}
+
+		assert.NoError(result.Transaction.VerifySignatures())
+	}
+
+	// a batch whose cached payload was swapped is refused as a whole
+	id = uuid.New().String()
+
+	opts, _, _, err = svc.InitializeSignTransactions(ctx, &InitializeSignTransactionsRequest{
+		Subject:       "user",
+		UserID:        "user-id",
+		TransactionID: id,
+		Transactions: []*BatchTransaction{
+			{Transaction: memoTransaction(t, wallet, wallet)},
+		},
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	tampered, err := account.NewSignBatchTransaction(id, "user", 0,
+		[]*solana.Transaction{memoTransaction(t, wallet, wallet)}, []bool{false})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	tampered.UserID = "user-id"
+	tampered.Batch.Transactions[0].Transaction = memoTransaction(t, solana.NewWallet().PublicKey(), wallet)
+
+	if err := svc.accounts.CacheTransaction(tampered, time.Minute); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	_, err = svc.FinalizeSignTransactions(ctx, "user", p.assert(opts))
+	assert.ErrorIs(err, account.ErrPayloadMismatch)
+}
+
+func TestFinalizeMismatch(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc, p := newTestServiceWithPasskeys(t)
+
+	for _, subject := range []string{"user", "other"} {
+		if _, err := svc.Wallet(ctx, subject); err != nil {
+			assert.Fail(err.Error())
+			return
+		}
+	}
+
+	// a transaction must name its user
+	opts, _, err := svc.InitializeSignMessage(ctx, &InitializeSignMessageRequest{
+		Subject:       "user",
+		TransactionID: uuid.New().String(),
+		Message:       []byte("hello"),
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	_, err = svc.FinalizeSignMessage(ctx, "user", p.assert(opts))
+	assert.ErrorIs(err, account.ErrTransactionMismatch)
+
+	id := uuid.New().String()
+
+	if _, _, err := svc.InitializeSignMessage(ctx, &InitializeSignMessageRequest{
+		Subject:       "user",
+		UserID:        "user-id",
+		TransactionID: id,
+		Message:       []byte("hello"),
+	}); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	// later ceremonies leave the cached transaction as it is
+	ceremony := func() *protocol.ParsedCredentialAssertionData {
+		opts, _, err := p.InitializeTransaction(&passkeys.InitializeTransactionRequest{
+			UserID:        "user-id",
+			TransactionID: id,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return p.assert(opts)
+	}
+
+	// another subject cannot finish the ceremony
+	_, err = svc.FinalizeSignMessage(ctx, "other", ceremony())
+	assert.ErrorIs(err, account.ErrTransactionMismatch)
+
+	// nor can another passkey user
+	p.user = "other-id"
+
+	_, err = svc.FinalizeSignMessage(ctx, "user", ceremony())
+	assert.ErrorIs(err, account.ErrTransactionMismatch)
+
+	p.user = ""
+
+	// the mismatches did not consume the transaction
+	if _, err := svc.FinalizeSignMessage(ctx, "user", ceremony()); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+}
+
+func TestFinalizeExpired(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc, p := newTestServiceWithPasskeys(t)
+
+	if _, err := svc.Wallet(ctx, "user"); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	id := uuid.New().String()
+
+	opts, _, err := svc.InitializeSignMessage(ctx, &InitializeSignMessageRequest{
+		Subject:       "user",
+		UserID:        "user-id",
+		TransactionID: id,
+		Message:       []byte("hello"),
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	expired, err := account.NewSignMessageTransaction(id, "user", 0, []byte("hello"))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	expired.UserID = "user-id"
+
+	if err := svc.accounts.CacheTransaction(expired, time.Millisecond); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	_, err = svc.FinalizeSignMessage(ctx, "user", p.assert(opts))
+	assert.ErrorIs(err, account.ErrTransactionExpired)
+}
+
+func TestFinalizeReplayed(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc, p := newTestServiceWithPasskeys(t)
+
+	if _, err := svc.Wallet(ctx, "user"); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	id := uuid.New().String()
+
+	opts, _, err := svc.InitializeSignMessage(ctx, &InitializeSignMessageRequest{
+		Subject:       "user",
+		UserID:        "user-id",
+		TransactionID: id,
+		Message:       []byte("hello"),
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if _, err := svc.FinalizeSignMessage(ctx, "user", p.assert(opts)); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	// a second ceremony for the consumed transaction
+	opts, _, err = p.InitializeTransaction(&passkeys.InitializeTransactionRequest{
+		UserID:        "user-id",
+		TransactionID: id,
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	_, err = svc.FinalizeSignMessage(ctx, "user", p.assert(opts))
+	assert.ErrorIs(err, account.ErrTransactionReplayed)
+}
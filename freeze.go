@@ -0,0 +1,126 @@
+package wallet
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+
+	"github.com/flarexio/identity/passkeys"
+	"github.com/flarexio/wallet/account"
+)
+
+func (svc *service) FreezeAccount(ctx context.Context, subject string, by string, reason string) (*account.Account, error) {
+	svc.accountsLock.Lock()
+	defer svc.accountsLock.Unlock()
+
+	a, err := svc.accounts.Find(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := a.Clone()
+
+	if err := updated.Freeze(by, reason); err != nil {
+		return nil, err
+	}
+
+	if err := svc.accounts.Save(updated); err != nil {
+		return nil, err
+	}
+
+	e := account.NewAuditEvent(subject, account.AuditAccountFrozen)
+	e.Details["by"] = by
+	e.Details["reason"] = reason
+
+	if err := svc.accounts.RecordAudit(e); err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (svc *service) InitializeUnfreezeAccount(ctx context.Context, req *InitializeUnfreezeAccountRequest) (*protocol.CredentialAssertion, string, error) {
+	a, err := svc.accounts.Find(req.Subject)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !a.IsFrozen() {
+		return nil, "", errors.New("account is not frozen")
+	}
+
+	r := &passkeys.InitializeTransactionRequest{
+		UserID:          req.UserID,
+		TransactionID:   req.TransactionID,
+		TransactionData: sha256.Sum256([]byte("wallet:unfreeze:" + req.Subject)),
+	}
+
+	opts, mediation, err := svc.passkeys.InitializeTransaction(r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	t, err := account.NewUnfreezeAccountTransaction(req.TransactionID, req.Subject, req.By)
+	if err != nil {
+		return nil, "", err
+	}
+
+	t.UserID = req.UserID
+
+	if err := svc.accounts.CacheTransaction(t, 120*time.Second); err != nil {
+		return nil, "", err
+	}
+
+	return opts, mediation, nil
+}
+
+// FinalizeUnfreezeAccount must be called by the admin who started the
+// unfreeze.
+func (svc *service) FinalizeUnfreezeAccount(ctx context.Context, req *FinalizeUnfreezeAccountRequest) (*account.Account, error) {
+	t, err := svc.finalize(req.Subject, req.Assertion)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Unfreeze == nil {
+		return nil, errors.New("invalid transaction")
+	}
+
+	if req.By == "" || req.By != t.Unfreeze.By {
+		return nil, account.ErrTransactionMismatch
+	}
+
+	return svc.unfreezeAccount(t.Subject, t.Unfreeze.By)
+}
+
+func (svc *service) unfreezeAccount(subject string, by string) (*account.Account, error) {
+	svc.accountsLock.Lock()
+	defer svc.accountsLock.Unlock()
+
+	a, err := svc.accounts.Find(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := a.Clone()
+
+	if err := updated.Unfreeze(); err != nil {
+		return nil, err
+	}
+
+	if err := svc.accounts.Save(updated); err != nil {
+		return nil, err
+	}
+
+	e := account.NewAuditEvent(subject, account.AuditAccountUnfrozen)
+	e.Details["by"] = by
+
+	if err := svc.accounts.RecordAudit(e); err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
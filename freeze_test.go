@@ -0,0 +1,211 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flarexio/wallet/account"
+)
+
+func TestFreezeAccount(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	if _, err := svc.Wallet(ctx, "user"); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	privkey := solana.NewWallet().PrivateKey
+
+	challenge, err := svc.CreateImportChallenge(ctx, "user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	sig, err := privkey.Sign([]byte(challenge.Challenge))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	a, err := svc.FreezeAccount(ctx, "user", "user", "lost phone")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.True(a.IsFrozen())
+
+	_, err = svc.FreezeAccount(ctx, "user", "admin", "again")
+	assert.ErrorIs(err, account.ErrAccountFrozen)
+
+	_, err = svc.SignMessage(ctx, "user", 0, []byte("hello"))
+	assert.ErrorIs(err, account.ErrAccountFrozen)
+
+	_, _, err = svc.InitializeSignMessage(ctx, &InitializeSignMessageRequest{
+		Subject: "user",
+		Message: []byte("hello"),
+	})
+	assert.ErrorIs(err, account.ErrAccountFrozen)
+
+	_, err = svc.exportKey(ctx, "user", 0, ExportFormatKeygen, nil)
+	assert.ErrorIs(err, account.ErrAccountFrozen)
+
+	_, err = svc.CreateWallet(ctx, "user", "savings")
+	assert.ErrorIs(err, account.ErrAccountFrozen)
+
+	_, err = svc.RenameWallet(ctx, "user", 0, "main")
+	assert.ErrorIs(err, account.ErrAccountFrozen)
+
+	_, err = svc.CreateImportChallenge(ctx, "user")
+	assert.ErrorIs(err, account.ErrAccountFrozen)
+
+	_, err = svc.ImportWallet(ctx, &ImportWalletRequest{
+		Subject:     "user",
+		ChallengeID: challenge.ID,
+		SecretKey:   json.RawMessage(`"` + privkey.String() + `"`),
+		Signature:   sig,
+	})
+	assert.ErrorIs(err, account.ErrAccountFrozen)
+
+	a, err = svc.unfreezeAccount("user", "admin")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.False(a.IsFrozen())
+
+	if _, err := svc.SignMessage(ctx, "user", 0, []byte("hello")); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	events, err := svc.accounts.AuditEvents("user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(events, 2)
+	assert.Equal(account.AuditAccountFrozen, events[0].Action)
+	assert.Equal(account.AuditAccountUnfrozen, events[1].Action)
+}
+
+func TestFinalizeUnfreezeAccount(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc, p := newTestServiceWithPasskeys(t)
+
+	if _, err := svc.Wallet(ctx, "user"); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if _, err := svc.FreezeAccount(ctx, "user", "user", "lost phone"); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	unfreeze := func() *protocol.ParsedCredentialAssertionData {
+		opts, _, err := svc.InitializeUnfreezeAccount(ctx, &InitializeUnfreezeAccountRequest{
+			Subject:       "user",
+			By:            "admin",
+			UserID:        "admin-id",
+			TransactionID: uuid.New().String(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return p.assert(opts)
+	}
+
+	// another admin cannot finish the unfreeze
+	_, err := svc.FinalizeUnfreezeAccount(ctx, &FinalizeUnfreezeAccountRequest{
+		Subject:   "user",
+		By:        "other-admin",
+		Assertion: unfreeze(),
+	})
+	assert.ErrorIs(err, account.ErrTransactionMismatch)
+
+	a, err := svc.accounts.Find("user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.True(a.IsFrozen())
+
+	a, err = svc.FinalizeUnfreezeAccount(ctx, &FinalizeUnfreezeAccountRequest{
+		Subject:   "user",
+		By:        "admin",
+		Assertion: unfreeze(),
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.False(a.IsFrozen())
+}
+
+func TestDeleteFrozenAccount(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc, p := newTestServiceWithPasskeys(t)
+
+	if _, err := svc.Wallet(ctx, "user"); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	// a deletion started before the freeze
+	opts, _, err := svc.InitializeDeleteAccount(ctx, &InitializeDeleteAccountRequest{
+		Subject:       "user",
+		UserID:        "user-id",
+		TransactionID: uuid.New().String(),
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if _, err := svc.FreezeAccount(ctx, "user", "admin", "suspicious"); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	_, err = svc.FinalizeDeleteAccount(ctx, "user", p.assert(opts))
+	assert.ErrorIs(err, account.ErrAccountFrozen)
+
+	_, _, err = svc.InitializeDeleteAccount(ctx, &InitializeDeleteAccountRequest{
+		Subject:       "user",
+		UserID:        "user-id",
+		TransactionID: uuid.New().String(),
+	})
+	assert.ErrorIs(err, account.ErrAccountFrozen)
+
+	a, err := svc.accounts.Find("user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.True(a.IsFrozen())
+}
@@ -0,0 +1,203 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/google/uuid"
+	"github.com/mr-tron/base58"
+
+	"github.com/flarexio/wallet/account"
+)
+
+var ErrInvalidChallenge = errors.New("invalid challenge")
+
+const importChallengeTTL = 5 * time.Minute
+
+type ImportChallenge struct {
+	ID        string    `json:"id"`
+	Challenge string    `json:"challenge"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ParseSecretKey accepts a keypair as written by solana-keygen, a JSON array
+// of 64 numbers, or as a base58 string.
+func ParseSecretKey(raw json.RawMessage) (solana.PrivateKey, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, errors.New("secret key is required")
+	}
+
+	var bs []byte
+	switch raw[0] {
+	case '[':
+		var keypair Keypair
+		if err := json.Unmarshal(raw, &keypair); err != nil {
+			return nil, err
+		}
+
+		bs = keypair
+
+	case '"':
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+
+		decoded, err := base58.Decode(s)
+		if err != nil {
+			return nil, err
+		}
+
+		bs = decoded
+
+	default:
+		return nil, errors.New("invalid secret key")
+	}
+
+	if len(bs) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid secret key")
+	}
+
+	// the public half is derived from the seed; a mismatch would store the
+	// wallet under an address its key cannot sign for
+	derived := ed25519.NewKeyFromSeed(bs[:ed25519.SeedSize])
+	if !bytes.Equal(derived[ed25519.SeedSize:], bs[ed25519.SeedSize:]) {
+		return nil, errors.New("secret key does not match its public key")
+	}
+
+	return solana.PrivateKey(bs), nil
+}
+
+func (svc *service) CreateImportChallenge(ctx context.Context, subject string) (*ImportChallenge, error) {
+	if _, err := svc.findActive(subject); err != nil && !errors.Is(err, account.ErrAccountNotFound) {
+		return nil, err
+	}
+
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	challenge := &ImportChallenge{
+		ID:        uuid.New().String(),
+		Challenge: "wallet:import:" + subject + ":" + base58.Encode(nonce),
+		ExpiresAt: time.Now().Add(importChallengeTTL),
+	}
+
+	t, err := account.NewImportChallengeTransaction(challenge.ID, subject, challenge.Challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := svc.accounts.CacheTransaction(t, importChallengeTTL); err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+func (svc *service) ImportWallet(ctx context.Context, req *ImportWalletRequest) (*account.Wallet, error) {
+	id, err := account.ParseTransactionID(req.ChallengeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// challenges are single use, whatever the outcome
+	t, err := svc.accounts.RemoveTransactionByID(id)
+	if err != nil {
+		if errors.Is(err, account.ErrTransactionNotFound) ||
+			errors.Is(err, account.ErrTransactionExpired) ||
+			errors.Is(err, account.ErrTransactionReplayed) {
+			return nil, ErrInvalidChallenge
+		}
+
+		return nil, err
+	}
+
+	if t.Import == nil || t.Subject != req.Subject {
+		return nil, ErrInvalidChallenge
+	}
+
+	privkey, err := ParseSecretKey(req.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if !req.Signature.Verify(privkey.PublicKey(), []byte(t.Import.Challenge)) {
+		return nil, errors.New("invalid signature")
+	}
+
+	svc.accountsLock.Lock()
+	defer svc.accountsLock.Unlock()
+
+	// checked under the lock so that concurrent imports of the same key
+	// cannot both pass
+	if _, err := svc.accounts.FindByWallet(privkey.PublicKey()); err == nil {
+		return nil, account.ErrWalletExists
+	} else if !errors.Is(err, account.ErrAccountNotFound) {
+		return nil, err
+	}
+
+	var (
+		updated *account.Account
+		w       *account.Wallet
+	)
+
+	a, err := svc.findActive(req.Subject)
+	switch {
+	case err == nil:
+		updated = a.Clone()
+
+		w, err = updated.ImportWallet(ed25519.PrivateKey(privkey), req.Label)
+		if err != nil {
+			return nil, err
+		}
+
+	case errors.Is(err, account.ErrAccountNotFound):
+		key, err := svc.keys.Key(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		updated, err = account.NewImportedAccount(req.Subject, key, ed25519.PrivateKey(privkey), req.Label)
+		if err != nil {
+			return nil, err
+		}
+
+		w = updated.Wallets[0]
+
+	default:
+		return nil, err
+	}
+
+	key, err := svc.keys.Key(ctx, updated.KeyVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := updated.Seal(ctx, key); err != nil {
+		return nil, err
+	}
+
+	if err := svc.accounts.Save(updated); err != nil {
+		return nil, err
+	}
+
+	e := account.NewAuditEvent(req.Subject, account.AuditKeyImported)
+	e.Details["wallet"] = strconv.Itoa(w.Index)
+	e.Details["address"] = w.PublicKey.String()
+
+	if err := svc.accounts.RecordAudit(e); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
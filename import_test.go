@@ -0,0 +1,207 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flarexio/wallet/account"
+)
+
+func TestParseSecretKey(t *testing.T) {
+	assert := assert.New(t)
+
+	privkey := solana.NewWallet().PrivateKey
+
+	keygen, err := json.Marshal(Keypair(privkey))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	parsed, err := ParseSecretKey(keygen)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(privkey, parsed)
+
+	parsed, err = ParseSecretKey(json.RawMessage(`"` + privkey.String() + `"`))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(privkey, parsed)
+
+	_, err = ParseSecretKey(json.RawMessage(`[1, 2, 3]`))
+	assert.Error(err)
+
+	// a public half that does not belong to the seed
+	mismatched := append(solana.PrivateKey{}, privkey[:32]...)
+	mismatched = append(mismatched, solana.NewWallet().PublicKey().Bytes()...)
+
+	_, err = ParseSecretKey(json.RawMessage(`"` + mismatched.String() + `"`))
+	assert.Error(err)
+}
+
+func TestImportWallet(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	privkey := solana.NewWallet().PrivateKey
+
+	challenge, err := svc.CreateImportChallenge(ctx, "user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	sig, err := privkey.Sign([]byte(challenge.Challenge))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	req := &ImportWalletRequest{
+		Subject:     "user",
+		ChallengeID: challenge.ID,
+		SecretKey:   json.RawMessage(`"` + privkey.String() + `"`),
+		Signature:   sig,
+	}
+
+	w, err := svc.ImportWallet(ctx, req)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.True(w.Imported)
+	assert.Equal(0, w.Index)
+	assert.Equal(privkey.PublicKey(), w.PublicKey)
+
+	// the challenge is consumed
+	_, err = svc.ImportWallet(ctx, req)
+	assert.ErrorIs(err, ErrInvalidChallenge)
+
+	derived, err := svc.CreateWallet(ctx, "user", "savings")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.False(derived.Imported)
+
+	sigs, err := svc.SignMessage(ctx, "user", 0, []byte("hello"))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.True(sigs.Verify(privkey.PublicKey(), []byte("hello")))
+
+	results, err := svc.RecoverAccount(ctx, "user", true)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(results, 2)
+	assert.Equal(RecoveryStatusVerified, results[0].Status)
+	assert.Equal(RecoveryStatusVerified, results[1].Status)
+
+	a, err := svc.accounts.Find("user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	a.Wallets[0].EncryptedKey = nil
+	if err := svc.accounts.Save(a); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	results, err = svc.RecoverAccount(ctx, "user", true)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(RecoveryStatusUnrecoverable, results[0].Status)
+
+	a, err = svc.accounts.Find("user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	// an imported wallet is never overwritten with a derived key
+	assert.Equal(privkey.PublicKey(), a.Wallets[0].PublicKey)
+}
+
+func TestImportWalletConcurrent(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	privkey := solana.NewWallet().PrivateKey
+
+	subjects := []string{"alice", "bob", "carol", "dave"}
+	reqs := make([]*ImportWalletRequest, len(subjects))
+	for i, subject := range subjects {
+		challenge, err := svc.CreateImportChallenge(ctx, subject)
+		if err != nil {
+			assert.Fail(err.Error())
+			return
+		}
+
+		sig, err := privkey.Sign([]byte(challenge.Challenge))
+		if err != nil {
+			assert.Fail(err.Error())
+			return
+		}
+
+		reqs[i] = &ImportWalletRequest{
+			Subject:     subject,
+			ChallengeID: challenge.ID,
+			SecretKey:   json.RawMessage(`"` + privkey.String() + `"`),
+			Signature:   sig,
+		}
+	}
+
+	errs := make([]error, len(reqs))
+
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = svc.ImportWallet(ctx, req)
+		}()
+	}
+	wg.Wait()
+
+	// the same key is imported exactly once
+	var imported int
+	for _, err := range errs {
+		if err == nil {
+			imported++
+			continue
+		}
+
+		assert.ErrorIs(err, account.ErrWalletExists)
+	}
+
+	assert.Equal(1, imported)
+}
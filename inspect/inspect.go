@@ -0,0 +1,194 @@
+// Package inspect decodes transactions into summaries that a user can read
+// before approving them.
+package inspect
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// MemoV1ProgramID is the legacy memo program, still used by some wallets.
+var MemoV1ProgramID = solana.MustPublicKeyFromBase58("Memo1UhkJRfHyvLMcVucJwxXeuD728EqVDDwQDxFMNo")
+
+const (
+	lamportsPerSignature = 5000
+
+	defaultComputeUnitLimit = 200_000
+	maxComputeUnitLimit     = 1_400_000
+)
+
+type Summary struct {
+	FeePayer     solana.PublicKey   `json:"fee_payer"`
+	Signers      []solana.PublicKey `json:"signers"`
+	Fee          *Fee               `json:"fee"`
+	Instructions []*Instruction     `json:"instructions"`
+	Warnings     []string           `json:"warnings,omitempty"`
+}
+
+// Fee is an estimate in lamports. Prioritization fees are charged on the
+// requested compute unit limit, not on the units actually consumed.
+type Fee struct {
+	Base             uint64 `json:"base"`
+	Priority         uint64 `json:"priority"`
+	Total            uint64 `json:"total"`
+	ComputeUnitLimit uint32 `json:"compute_unit_limit"`
+	ComputeUnitPrice uint64 `json:"compute_unit_price"`
+}
+
+type Instruction struct {
+	Program   string            `json:"program"`
+	ProgramID solana.PublicKey  `json:"program_id"`
+	Type      string            `json:"type"`
+	From      *solana.PublicKey `json:"from,omitempty"`
+	To        *solana.PublicKey `json:"to,omitempty"`
+	Authority *solana.PublicKey `json:"authority,omitempty"`
+	Mint      *solana.PublicKey `json:"mint,omitempty"`
+	Owner     *solana.PublicKey `json:"owner,omitempty"`
+	Amount    *uint64           `json:"amount,omitempty"`
+	Decimals  *uint8            `json:"decimals,omitempty"`
+	Memo      string            `json:"memo,omitempty"`
+}
+
+// decoder fills in an instruction from its data and resolved accounts and
+// returns any warning the user should see.
+type decoder func(inst *Instruction, accounts []solana.PublicKey, data []byte) (warning string, err error)
+
+var programs = map[solana.PublicKey]struct {
+	name   string
+	decode decoder
+}{
+	solana.SystemProgramID:                    {"system", decodeSystem},
+	solana.TokenProgramID:                     {"spl-token", decodeToken},
+	solana.Token2022ProgramID:                 {"spl-token-2022", decodeToken},
+	solana.SPLAssociatedTokenAccountProgramID: {"associated-token-account", decodeAssociatedTokenAccount},
+	solana.ComputeBudget:                      {"compute-budget", decodeComputeBudget},
+	solana.MemoProgramID:                      {"memo", decodeMemo},
+	MemoV1ProgramID:                           {"memo", decodeMemo},
+}
+
+func Inspect(tx *solana.Transaction) (*Summary, error) {
+	msg := tx.Message
+
+	if len(msg.AccountKeys) == 0 {
+		return nil, fmt.Errorf("transaction has no accounts")
+	}
+
+	// address table lookups are only available once resolved against the chain
+	keys, err := msg.GetAllKeys()
+	if err != nil {
+		keys = msg.AccountKeys
+	}
+
+	summary := &Summary{
+		FeePayer:     msg.AccountKeys[0],
+		Signers:      msg.Signers(),
+		Instructions: make([]*Instruction, 0, len(msg.Instructions)),
+		Warnings:     make([]string, 0),
+	}
+
+	var (
+		limit    *uint32
+		price    uint64
+		budgeted int
+	)
+
+	for i, compiled := range msg.Instructions {
+		if int(compiled.ProgramIDIndex) >= len(keys) {
+			return nil, fmt.Errorf("instruction %d: invalid program index", i)
+		}
+
+		programID := keys[compiled.ProgramIDIndex]
+
+		inst := &Instruction{
+			ProgramID: programID,
+			Program:   "unknown",
+			Type:      "unknown",
+		}
+
+		summary.Instructions = append(summary.Instructions, inst)
+
+		program, ok := programs[programID]
+		if !ok {
+			summary.Warnings = append(summary.Warnings,
+				fmt.Sprintf("instruction %d: unknown program %s", i, programID))
+			continue
+		}
+
+		inst.Program = program.name
+
+		accounts := make([]solana.PublicKey, 0, len(compiled.Accounts))
+		for _, idx := range compiled.Accounts {
+			if int(idx) >= len(keys) {
+				accounts = nil
+				break
+			}
+
+			accounts = append(accounts, keys[idx])
+		}
+
+		if accounts == nil {
+			inst.Type = "unresolved"
+			summary.Warnings = append(summary.Warnings,
+				fmt.Sprintf("instruction %d: accounts from address lookup tables are not resolved", i))
+			continue
+		}
+
+		warning, err := program.decode(inst, accounts, compiled.Data)
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d: %w", i, err)
+		}
+
+		if warning != "" {
+			summary.Warnings = append(summary.Warnings, fmt.Sprintf("instruction %d: %s", i, warning))
+		}
+
+		if programID.Equals(solana.ComputeBudget) {
+			switch inst.Type {
+			case "set_compute_unit_limit":
+				units := uint32(*inst.Amount)
+				limit = &units
+
+			case "set_compute_unit_price":
+				price = *inst.Amount
+			}
+
+			continue
+		}
+
+		budgeted++
+	}
+
+	summary.Fee = estimateFee(int(msg.Header.NumRequiredSignatures), limit, price, budgeted)
+
+	return summary, nil
+}
+
+func estimateFee(signatures int, limit *uint32, price uint64, instructions int) *Fee {
+	units := uint32(min(instructions*defaultComputeUnitLimit, maxComputeUnitLimit))
+	if limit != nil {
+		units = min(*limit, maxComputeUnitLimit)
+	}
+
+	fee := &Fee{
+		Base:             uint64(signatures) * lamportsPerSignature,
+		ComputeUnitLimit: units,
+		ComputeUnitPrice: price,
+	}
+
+	// price is in micro-lamports per compute unit, rounded up
+	micro := uint64(units) * price
+	fee.Priority = (micro + 999_999) / 1_000_000
+	fee.Total = fee.Base + fee.Priority
+
+	return fee
+}
+
+func account(accounts []solana.PublicKey, i int) *solana.PublicKey {
+	if i >= len(accounts) {
+		return nil
+	}
+
+	key := accounts[i]
+	return &key
+}
@@ -0,0 +1,74 @@
+package inspect
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/stretchr/testify/assert"
+
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+)
+
+func TestInspect(t *testing.T) {
+	assert := assert.New(t)
+
+	payer := solana.NewWallet().PublicKey()
+	recipient := solana.NewWallet().PublicKey()
+	mint := solana.NewWallet().PublicKey()
+	source := solana.NewWallet().PublicKey()
+	destination := solana.NewWallet().PublicKey()
+	unknown := solana.NewWallet().PublicKey()
+
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			computebudget.NewSetComputeUnitLimitInstruction(300_000).Build(),
+			computebudget.NewSetComputeUnitPriceInstruction(10_000).Build(),
+			system.NewTransferInstruction(1_000_000, payer, recipient).Build(),
+			token.NewTransferCheckedInstruction(2_500_000, 6, source, mint, destination, payer, nil).Build(),
+			solana.NewInstruction(solana.MemoProgramID, nil, []byte("invoice 42")),
+			solana.NewInstruction(unknown, nil, []byte{1}),
+		},
+		solana.Hash{},
+		solana.TransactionPayer(payer),
+	)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	summary, err := Inspect(tx)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(payer, summary.FeePayer)
+	assert.Len(summary.Instructions, 6)
+
+	transfer := summary.Instructions[2]
+	assert.Equal("system", transfer.Program)
+	assert.Equal("transfer", transfer.Type)
+	assert.Equal(payer, *transfer.From)
+	assert.Equal(recipient, *transfer.To)
+	assert.Equal(uint64(1_000_000), *transfer.Amount)
+
+	tokenTransfer := summary.Instructions[3]
+	assert.Equal("spl-token", tokenTransfer.Program)
+	assert.Equal("transfer_checked", tokenTransfer.Type)
+	assert.Equal(mint, *tokenTransfer.Mint)
+	assert.Equal(destination, *tokenTransfer.To)
+	assert.Equal(uint64(2_500_000), *tokenTransfer.Amount)
+	assert.Equal(uint8(6), *tokenTransfer.Decimals)
+
+	assert.Equal("invoice 42", summary.Instructions[4].Memo)
+
+	assert.Equal("unknown", summary.Instructions[5].Program)
+	assert.Len(summary.Warnings, 1)
+
+	// 300k units at 10k micro-lamports is 3000 lamports on top of one signature
+	assert.Equal(uint64(5000), summary.Fee.Base)
+	assert.Equal(uint64(3000), summary.Fee.Priority)
+	assert.Equal(uint64(8000), summary.Fee.Total)
+}
@@ -0,0 +1,359 @@
+package inspect
+
+import (
+	"encoding/binary"
+	"errors"
+	"unicode/utf8"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/programs/token"
+
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+)
+
+var errInvalidData = errors.New("invalid instruction data")
+
+func decodeSystem(inst *Instruction, accounts []solana.PublicKey, data []byte) (string, error) {
+	if len(data) < 4 {
+		return "", errInvalidData
+	}
+
+	switch binary.LittleEndian.Uint32(data) {
+	case system.Instruction_CreateAccount:
+		inst.Type = "create_account"
+		inst.From = account(accounts, 0)
+		inst.To = account(accounts, 1)
+
+		if len(data) < 52 {
+			return "", errInvalidData
+		}
+
+		lamports := binary.LittleEndian.Uint64(data[4:])
+		owner := solana.PublicKeyFromBytes(data[20:52])
+
+		inst.Amount = &lamports
+		inst.Owner = &owner
+
+	case system.Instruction_Assign:
+		inst.Type = "assign"
+		inst.From = account(accounts, 0)
+
+		if len(data) < 36 {
+			return "", errInvalidData
+		}
+
+		owner := solana.PublicKeyFromBytes(data[4:36])
+		inst.Owner = &owner
+
+		return "assigns account ownership to another program", nil
+
+	case system.Instruction_Transfer:
+		inst.Type = "transfer"
+		inst.From = account(accounts, 0)
+		inst.To = account(accounts, 1)
+
+		if len(data) < 12 {
+			return "", errInvalidData
+		}
+
+		lamports := binary.LittleEndian.Uint64(data[4:])
+		inst.Amount = &lamports
+
+	case system.Instruction_TransferWithSeed:
+		inst.Type = "transfer_with_seed"
+		inst.From = account(accounts, 0)
+		inst.To = account(accounts, 2)
+
+		if len(data) < 12 {
+			return "", errInvalidData
+		}
+
+		lamports := binary.LittleEndian.Uint64(data[4:])
+		inst.Amount = &lamports
+
+	case system.Instruction_CreateAccountWithSeed:
+		inst.Type = "create_account_with_seed"
+		inst.From = account(accounts, 0)
+		inst.To = account(accounts, 1)
+
+	case system.Instruction_AdvanceNonceAccount:
+		inst.Type = "advance_nonce_account"
+
+	case system.Instruction_WithdrawNonceAccount:
+		inst.Type = "withdraw_nonce_account"
+		inst.From = account(accounts, 0)
+		inst.To = account(accounts, 1)
+
+		if len(data) < 12 {
+			return "", errInvalidData
+		}
+
+		lamports := binary.LittleEndian.Uint64(data[4:])
+		inst.Amount = &lamports
+
+	case system.Instruction_AuthorizeNonceAccount:
+		inst.Type = "authorize_nonce_account"
+		return "changes the authority of a nonce account", nil
+
+	case system.Instruction_Allocate, system.Instruction_AllocateWithSeed:
+		inst.Type = "allocate"
+
+	case system.Instruction_AssignWithSeed:
+		inst.Type = "assign_with_seed"
+		return "assigns account ownership to another program", nil
+
+	case system.Instruction_InitializeNonceAccount:
+		inst.Type = "initialize_nonce_account"
+	}
+
+	return "", nil
+}
+
+// decodeToken covers SPL Token and the instructions Token-2022 shares with it.
+// Token-2022 extension instructions are left as unknown.
+func decodeToken(inst *Instruction, accounts []solana.PublicKey, data []byte) (string, error) {
+	if len(data) < 1 {
+		return "", errInvalidData
+	}
+
+	amount := func(offset int) error {
+		if len(data) < offset+8 {
+			return errInvalidData
+		}
+
+		v := binary.LittleEndian.Uint64(data[offset:])
+		inst.Amount = &v
+		return nil
+	}
+
+	decimals := func(offset int) error {
+		if len(data) < offset+1 {
+			return errInvalidData
+		}
+
+		v := data[offset]
+		inst.Decimals = &v
+		return nil
+	}
+
+	switch data[0] {
+	case token.Instruction_Transfer:
+		inst.Type = "transfer"
+		inst.From = account(accounts, 0)
+		inst.To = account(accounts, 1)
+		inst.Authority = account(accounts, 2)
+
+		return "", amount(1)
+
+	case token.Instruction_TransferChecked:
+		inst.Type = "transfer_checked"
+		inst.From = account(accounts, 0)
+		inst.Mint = account(accounts, 1)
+		inst.To = account(accounts, 2)
+		inst.Authority = account(accounts, 3)
+
+		if err := amount(1); err != nil {
+			return "", err
+		}
+
+		return "", decimals(9)
+
+	case token.Instruction_Approve:
+		inst.Type = "approve"
+		inst.From = account(accounts, 0)
+		inst.To = account(accounts, 1)
+		inst.Authority = account(accounts, 2)
+
+		if err := amount(1); err != nil {
+			return "", err
+		}
+
+		return "delegates spending of tokens to another account", nil
+
+	case token.Instruction_ApproveChecked:
+		inst.Type = "approve_checked"
+		inst.From = account(accounts, 0)
+		inst.Mint = account(accounts, 1)
+		inst.To = account(accounts, 2)
+		inst.Authority = account(accounts, 3)
+
+		if err := amount(1); err != nil {
+			return "", err
+		}
+
+		if err := decimals(9); err != nil {
+			return "", err
+		}
+
+		return "delegates spending of tokens to another account", nil
+
+	case token.Instruction_Revoke:
+		inst.Type = "revoke"
+		inst.From = account(accounts, 0)
+		inst.Authority = account(accounts, 1)
+
+	case token.Instruction_SetAuthority:
+		inst.Type = "set_authority"
+		inst.From = account(accounts, 0)
+		inst.Authority = account(accounts, 1)
+
+		// authority type, then an optional new authority
+		if len(data) >= 35 && data[2] == 1 {
+			newAuthority := solana.PublicKeyFromBytes(data[3:35])
+			inst.To = &newAuthority
+		}
+
+		return "changes the authority of a token account or mint", nil
+
+	case token.Instruction_MintTo:
+		inst.Type = "mint_to"
+		inst.Mint = account(accounts, 0)
+		inst.To = account(accounts, 1)
+		inst.Authority = account(accounts, 2)
+
+		return "", amount(1)
+
+	case token.Instruction_MintToChecked:
+		inst.Type = "mint_to_checked"
+		inst.Mint = account(accounts, 0)
+		inst.To = account(accounts, 1)
+		inst.Authority = account(accounts, 2)
+
+		if err := amount(1); err != nil {
+			return "", err
+		}
+
+		return "", decimals(9)
+
+	case token.Instruction_Burn:
+		inst.Type = "burn"
+		inst.From = account(accounts, 0)
+		inst.Mint = account(accounts, 1)
+		inst.Authority = account(accounts, 2)
+
+		return "", amount(1)
+
+	case token.Instruction_BurnChecked:
+		inst.Type = "burn_checked"
+		inst.From = account(accounts, 0)
+		inst.Mint = account(accounts, 1)
+		inst.Authority = account(accounts, 2)
+
+		if err := amount(1); err != nil {
+			return "", err
+		}
+
+		return "", decimals(9)
+
+	case token.Instruction_CloseAccount:
+		inst.Type = "close_account"
+		inst.From = account(accounts, 0)
+		inst.To = account(accounts, 1)
+		inst.Authority = account(accounts, 2)
+
+		return "closes a token account and moves its rent to the destination", nil
+
+	case token.Instruction_FreezeAccount:
+		inst.Type = "freeze_account"
+		inst.From = account(accounts, 0)
+		inst.Mint = account(accounts, 1)
+		inst.Authority = account(accounts, 2)
+
+	case token.Instruction_ThawAccount:
+		inst.Type = "thaw_account"
+		inst.From = account(accounts, 0)
+		inst.Mint = account(accounts, 1)
+		inst.Authority = account(accounts, 2)
+
+	case token.Instruction_InitializeAccount,
+		token.Instruction_InitializeAccount2,
+		token.Instruction_InitializeAccount3:
+		inst.Type = "initialize_account"
+		inst.To = account(accounts, 0)
+		inst.Mint = account(accounts, 1)
+
+	case token.Instruction_InitializeMint, token.Instruction_InitializeMint2:
+		inst.Type = "initialize_mint"
+		inst.Mint = account(accounts, 0)
+
+	case token.Instruction_SyncNative:
+		inst.Type = "sync_native"
+		inst.To = account(accounts, 0)
+	}
+
+	return "", nil
+}
+
+func decodeAssociatedTokenAccount(inst *Instruction, accounts []solana.PublicKey, data []byte) (string, error) {
+	inst.From = account(accounts, 0)
+	inst.To = account(accounts, 1)
+	inst.Owner = account(accounts, 2)
+	inst.Mint = account(accounts, 3)
+
+	var discriminator byte
+	if len(data) > 0 {
+		discriminator = data[0]
+	}
+
+	switch discriminator {
+	case 0:
+		inst.Type = "create"
+
+	case 1:
+		inst.Type = "create_idempotent"
+
+	case 2:
+		inst.Type = "recover_nested"
+		inst.From = account(accounts, 0)
+		inst.Mint = account(accounts, 1)
+		inst.To = account(accounts, 2)
+		inst.Owner = account(accounts, 5)
+	}
+
+	return "", nil
+}
+
+func decodeComputeBudget(inst *Instruction, accounts []solana.PublicKey, data []byte) (string, error) {
+	if len(data) < 1 {
+		return "", errInvalidData
+	}
+
+	switch data[0] {
+	case computebudget.Instruction_SetComputeUnitLimit:
+		inst.Type = "set_compute_unit_limit"
+
+		if len(data) < 5 {
+			return "", errInvalidData
+		}
+
+		units := uint64(binary.LittleEndian.Uint32(data[1:]))
+		inst.Amount = &units
+
+	case computebudget.Instruction_SetComputeUnitPrice:
+		inst.Type = "set_compute_unit_price"
+
+		if len(data) < 9 {
+			return "", errInvalidData
+		}
+
+		price := binary.LittleEndian.Uint64(data[1:])
+		inst.Amount = &price
+
+	case computebudget.Instruction_RequestHeapFrame:
+		inst.Type = "request_heap_frame"
+	}
+
+	return "", nil
+}
+
+func decodeMemo(inst *Instruction, accounts []solana.PublicKey, data []byte) (string, error) {
+	inst.Type = "memo"
+
+	if !utf8.Valid(data) {
+		return "", errors.New("memo is not valid utf-8")
+	}
+
+	inst.Memo = string(data)
+	return "", nil
+}
@@ -4,100 +4,232 @@ import (
 	"context"
 	"crypto"
 	"crypto/ed25519"
-	"crypto/rand"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
+	"fmt"
 	"io"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"cloud.google.com/go/kms/apiv1/kmspb"
+	"github.com/googleapis/gax-go/v2"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	kms "cloud.google.com/go/kms/apiv1"
 
 	"github.com/flarexio/wallet/conf"
 )
 
-func NewGoogleKeysService(cfg conf.GoogleKeyConfig) (Service, error) {
+func NewGoogleKeysService(cfg *conf.GoogleKeyConfig) (Service, error) {
+	if cfg == nil {
+		return nil, errors.New("google key config is required")
+	}
+
 	ctx := context.Background()
 	client, err := kms.NewKeyManagementClient(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	req := &kmspb.ListCryptoKeyVersionsRequest{
-		Parent: cfg.Path(),
+	ctx, cancel := context.WithCancel(ctx)
+
+	svc := &googleKeyService{
+		client:  client,
+		parent:  cfg.Path(),
+		policy:  newRetryPolicy(cfg.Timeout, cfg.Retry),
+		pubkeys: make(map[string]crypto.PublicKey),
+		cancel:  cancel,
 	}
 
-	keyVersions := make([]*kmspb.CryptoKeyVersion, 0)
+	if err := svc.refresh(ctx); err != nil {
+		cancel()
+		client.Close()
+		return nil, err
+	}
 
-	it := client.ListCryptoKeyVersions(ctx, req)
-	for {
-		version, err := it.Next()
-		if err == iterator.Done {
-			break
-		}
+	interval := cfg.RefreshInterval
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
 
-		if err != nil {
-			return nil, err
-		}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
 
-		keyVersions = append(keyVersions, version)
-	}
+		for {
+			select {
+			case <-ctx.Done():
+				return
 
-	return &googleKeyService{
-		client:      client,
-		keyVersions: keyVersions,
-	}, nil
+			case <-ticker.C:
+				svc.refresh(ctx)
+			}
+		}
+	}()
+
+	return svc, nil
 }
 
 type googleKeyService struct {
 	client      *kms.KeyManagementClient
-	keyVersions []*kmspb.CryptoKeyVersion
+	parent      string
+	policy      retryPolicy
+	keyVersions map[int]*kmspb.CryptoKeyVersion
+	latest      int
+	pubkeys     map[string]crypto.PublicKey
+	cancel      context.CancelFunc
 	sync.RWMutex
 }
 
-func (svc *googleKeyService) Key(v ...int) (Key, error) {
-	count := len(svc.keyVersions)
-	if count == 0 {
-		return nil, errors.New("key empty")
+// isGoogleTransient reports whether a KMS error is worth retrying.
+func isGoogleTransient(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted,
+		codes.Aborted, codes.Internal:
+		return true
+	default:
+		return false
 	}
+}
+
+// noRetry disables the client's built-in retries in favor of the configured policy.
+var noRetry = gax.WithRetry(nil)
 
-	ver := count - 1
-	if len(v) > 0 {
-		ver = v[0] - 1
+func (svc *googleKeyService) refresh(ctx context.Context) error {
+	req := &kmspb.ListCryptoKeyVersionsRequest{
+		Parent: svc.parent,
 	}
 
-	if ver < 0 {
+	var keyVersions []*kmspb.CryptoKeyVersion
+	if err := svc.policy.do(ctx, isGoogleTransient, func(ctx context.Context) error {
+		keyVersions = make([]*kmspb.CryptoKeyVersion, 0)
+
+		it := svc.client.ListCryptoKeyVersions(ctx, req, noRetry)
+		for {
+			version, err := it.Next()
+			if err == iterator.Done {
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+
+			keyVersions = append(keyVersions, version)
+		}
+	}); err != nil {
+		return err
+	}
+
+	versions, latest := indexKeyVersions(keyVersions)
+
+	svc.Lock()
+	svc.keyVersions = versions
+	svc.latest = latest
+	svc.Unlock()
+
+	return nil
+}
+
+// indexKeyVersions maps key versions by their version ID and returns the
+// newest version that is enabled.
+func indexKeyVersions(keyVersions []*kmspb.CryptoKeyVersion) (map[int]*kmspb.CryptoKeyVersion, int) {
+	versions := make(map[int]*kmspb.CryptoKeyVersion)
+
+	var latest int
+	for _, version := range keyVersions {
+		ver := versionFromName(version.Name)
+		if ver < 1 {
+			continue
+		}
+
+		versions[ver] = version
+
+		if version.State == kmspb.CryptoKeyVersion_ENABLED && ver > latest {
+			latest = ver
+		}
+	}
+
+	return versions, latest
+}
+
+func lookupKeyVersion(versions map[int]*kmspb.CryptoKeyVersion, latest int, v ...int) (*kmspb.CryptoKeyVersion, error) {
+	if len(v) == 0 {
+		if latest == 0 {
+			return nil, errors.New("no enabled key version")
+		}
+
+		return versions[latest], nil
+	}
+
+	ver := v[0]
+	if ver < 1 {
 		return nil, errors.New("invalid version")
 	}
 
-	if ver >= count {
+	version, ok := versions[ver]
+	if !ok {
 		return nil, errors.New("key not found")
 	}
 
+	switch version.State {
+	case kmspb.CryptoKeyVersion_ENABLED:
+		return version, nil
+
+	case kmspb.CryptoKeyVersion_DISABLED:
+		return nil, fmt.Errorf("%w: version %d", ErrKeyVersionDisabled, ver)
+
+	case kmspb.CryptoKeyVersion_DESTROYED, kmspb.CryptoKeyVersion_DESTROY_SCHEDULED:
+		return nil, fmt.Errorf("%w: version %d", ErrKeyVersionDestroyed, ver)
+
+	default:
+		return nil, fmt.Errorf("key version %d is not available: %s", ver, version.State)
+	}
+}
+
+func (svc *googleKeyService) Key(ctx context.Context, v ...int) (Key, error) {
 	svc.RLock()
-	defer svc.RUnlock()
+	version, err := lookupKeyVersion(svc.keyVersions, svc.latest, v...)
+	svc.RUnlock()
+
+	if err != nil {
+		if errors.Is(err, ErrKeyVersionDisabled) || errors.Is(err, ErrKeyVersionDestroyed) {
+			return nil, err
+		}
+
+		// pick up versions created since the last refresh
+		if err := svc.refresh(ctx); err != nil {
+			return nil, err
+		}
 
-	keyVersion := svc.keyVersions[ver]
+		svc.RLock()
+		version, err = lookupKeyVersion(svc.keyVersions, svc.latest, v...)
+		svc.RUnlock()
+
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	return svc.NewKey(keyVersion)
+	return svc.NewKey(ctx, version)
 }
 
-func (svc *googleKeyService) Signature(data []byte, ver ...int) ([]byte, error) {
-	key, err := svc.Key(ver...)
+func (svc *googleKeyService) Signature(ctx context.Context, data []byte, ver ...int) ([]byte, error) {
+	key, err := svc.Key(ctx, ver...)
 	if err != nil {
 		return nil, err
 	}
 
-	return key.Signature(data)
+	return key.Signature(ctx, data)
 }
 
-func (svc *googleKeyService) Verify(data []byte, sig []byte, ver ...int) (bool, error) {
-	key, err := svc.Key(ver...)
+func (svc *googleKeyService) Verify(ctx context.Context, data []byte, sig []byte, ver ...int) (bool, error) {
+	key, err := svc.Key(ctx, ver...)
 	if err != nil {
 		return false, err
 	}
@@ -106,15 +238,27 @@ func (svc *googleKeyService) Verify(data []byte, sig []byte, ver ...int) (bool,
 }
 
 func (svc *googleKeyService) Close() error {
+	svc.cancel()
 	return svc.client.Close()
 }
 
-func (svc *googleKeyService) NewKey(version *kmspb.CryptoKeyVersion) (Key, error) {
-	ctx := context.Background()
-	resp, err := svc.client.GetPublicKey(ctx, &kmspb.GetPublicKeyRequest{
-		Name: version.Name,
-	})
-	if err != nil {
+func (svc *googleKeyService) publicKey(ctx context.Context, version *kmspb.CryptoKeyVersion) (crypto.PublicKey, error) {
+	svc.RLock()
+	pubkey, ok := svc.pubkeys[version.Name]
+	svc.RUnlock()
+
+	if ok {
+		return pubkey, nil
+	}
+
+	var resp *kmspb.PublicKey
+	if err := svc.policy.do(ctx, isGoogleTransient, func(ctx context.Context) error {
+		var err error
+		resp, err = svc.client.GetPublicKey(ctx, &kmspb.GetPublicKeyRequest{
+			Name: version.Name,
+		}, noRetry)
+		return err
+	}); err != nil {
 		return nil, err
 	}
 
@@ -128,7 +272,6 @@ func (svc *googleKeyService) NewKey(version *kmspb.CryptoKeyVersion) (Key, error
 		return nil, err
 	}
 
-	var pubkey crypto.PublicKey
 	switch key := pub.(type) {
 	case ed25519.PublicKey:
 		pubkey = key
@@ -136,11 +279,32 @@ func (svc *googleKeyService) NewKey(version *kmspb.CryptoKeyVersion) (Key, error
 		return nil, errors.New("unsupported algorithm")
 	}
 
-	sign := func(client *kms.KeyManagementClient) AsymmetricSign {
+	// public keys of a key version never change
+	svc.Lock()
+	svc.pubkeys[version.Name] = pubkey
+	svc.Unlock()
+
+	return pubkey, nil
+}
+
+func (svc *googleKeyService) NewKey(ctx context.Context, version *kmspb.CryptoKeyVersion) (Key, error) {
+	pubkey, err := svc.publicKey(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	sign := func(client *kms.KeyManagementClient, policy retryPolicy) AsymmetricSign {
 		return func(ctx context.Context, req *kmspb.AsymmetricSignRequest) (*kmspb.AsymmetricSignResponse, error) {
-			return client.AsymmetricSign(ctx, req)
+			var resp *kmspb.AsymmetricSignResponse
+			err := policy.do(ctx, isGoogleTransient, func(ctx context.Context) error {
+				var err error
+				resp, err = client.AsymmetricSign(ctx, req, noRetry)
+				return err
+			})
+
+			return resp, err
 		}
-	}(svc.client)
+	}(svc.client, svc.policy)
 
 	return &googleKey{
 		CryptoKeyVersion: version,
@@ -165,19 +329,22 @@ func (key *googleKey) Public() crypto.PublicKey {
 }
 
 func (key *googleKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) (signature []byte, err error) {
+	return key.Signature(context.Background(), digest)
+}
+
+func (key *googleKey) Signature(ctx context.Context, data []byte) ([]byte, error) {
 	var req *kmspb.AsymmetricSignRequest
 	switch key.Algorithm {
 	case kmspb.CryptoKeyVersion_EC_SIGN_ED25519:
 		req = &kmspb.AsymmetricSignRequest{
 			Name: key.Name,
-			Data: digest,
+			Data: data,
 		}
 
 	default:
 		return nil, errors.New("unsupported algorithm")
 	}
 
-	ctx := context.Background()
 	resp, err := key.AsymmetricSign(ctx, req)
 	if err != nil {
 		return nil, err
@@ -190,10 +357,6 @@ func (key *googleKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts
 	return resp.Signature, nil
 }
 
-func (key *googleKey) Signature(data []byte) ([]byte, error) {
-	return key.Sign(rand.Reader, data, crypto.Hash(0))
-}
-
 func (key *googleKey) Verify(data []byte, sig []byte) (bool, error) {
 	switch key.Algorithm {
 	case kmspb.CryptoKeyVersion_EC_SIGN_ED25519:
@@ -210,7 +373,13 @@ func (key *googleKey) Verify(data []byte, sig []byte) (bool, error) {
 }
 
 func (key *googleKey) Version() int {
-	parts := strings.Split(key.Name, "/")
+	return versionFromName(key.Name)
+}
+
+// versionFromName parses the version ID out of
+// projects/*/locations/*/keyRings/*/cryptoKeys/*/cryptoKeyVersions/*.
+func versionFromName(name string) int {
+	parts := strings.Split(name, "/")
 	if len(parts) < 10 {
 		return 0
 	}
@@ -0,0 +1,102 @@
+package keys
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+var ErrInvalidPassphrase = errors.New("invalid passphrase")
+
+// EncryptedData is a payload sealed with AES-256-GCM under a scrypt-derived key.
+type EncryptedData struct {
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func EncryptWithPassphrase(passphrase []byte, plaintext []byte, additionalData []byte) (*EncryptedData, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("passphrase is required")
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	key, err := scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return &EncryptedData{
+		KDF:        "scrypt",
+		N:          scryptN,
+		R:          scryptR,
+		P:          scryptP,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, additionalData),
+	}, nil
+}
+
+func (data *EncryptedData) Decrypt(passphrase []byte, additionalData []byte) ([]byte, error) {
+	if data.KDF != "scrypt" {
+		return nil, errors.New("unsupported kdf")
+	}
+
+	key, err := scrypt.Key(passphrase, data.Salt, data.N, data.R, data.P, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data.Nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid nonce")
+	}
+
+	plaintext, err := aead.Open(nil, data.Nonce, data.Ciphertext, additionalData)
+	if err != nil {
+		return nil, ErrInvalidPassphrase
+	}
+
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
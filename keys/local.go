@@ -0,0 +1,269 @@
+package keys
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/flarexio/wallet/conf"
+)
+
+func NewLocalKeysService(cfg *conf.LocalKeyConfig) (Service, error) {
+	if cfg == nil {
+		return nil, errors.New("local key config is required")
+	}
+
+	svc := &localKeyService{
+		path:       filepath.Join(cfg.Path, cfg.Name),
+		passphrase: []byte(cfg.Passphrase),
+		keys:       make(map[int]*localKey),
+	}
+
+	if err := svc.load(); err != nil {
+		return nil, err
+	}
+
+	if len(svc.keys) == 0 {
+		if _, err := svc.rotate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return svc, nil
+}
+
+// RotateLocalKey appends a new master key version to the local keystore.
+func RotateLocalKey(cfg *conf.LocalKeyConfig) (int, error) {
+	s, err := NewLocalKeysService(cfg)
+	if err != nil {
+		return 0, err
+	}
+
+	svc := s.(*localKeyService)
+	defer svc.Close()
+
+	key, err := svc.rotate()
+	if err != nil {
+		return 0, err
+	}
+
+	return key.Version(), nil
+}
+
+type localKeystore struct {
+	Versions []*localKeystoreEntry `json:"versions"`
+}
+
+type localKeystoreEntry struct {
+	Version   int               `json:"version"`
+	PublicKey ed25519.PublicKey `json:"public_key"`
+	Seed      *EncryptedData    `json:"seed"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type localKeyService struct {
+	path       string
+	passphrase []byte
+	store      localKeystore
+	keys       map[int]*localKey
+	latest     int
+	sync.RWMutex
+}
+
+func (svc *localKeyService) load() error {
+	bs, err := os.ReadFile(svc.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	var store localKeystore
+	if err := json.Unmarshal(bs, &store); err != nil {
+		return err
+	}
+
+	for _, entry := range store.Versions {
+		ad := []byte(strconv.Itoa(entry.Version))
+
+		seed, err := entry.Seed.Decrypt(svc.passphrase, ad)
+		if err != nil {
+			return err
+		}
+
+		if len(seed) != ed25519.SeedSize {
+			return errors.New("invalid seed")
+		}
+
+		privkey := ed25519.NewKeyFromSeed(seed)
+		if !entry.PublicKey.Equal(privkey.Public()) {
+			return errors.New("public key mismatch")
+		}
+
+		svc.keys[entry.Version] = &localKey{entry.Version, privkey}
+		svc.latest = max(svc.latest, entry.Version)
+	}
+
+	svc.store = store
+
+	return nil
+}
+
+func (svc *localKeyService) rotate() (Key, error) {
+	svc.Lock()
+	defer svc.Unlock()
+
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+
+	version := svc.latest + 1
+	ad := []byte(strconv.Itoa(version))
+
+	encrypted, err := EncryptWithPassphrase(svc.passphrase, seed, ad)
+	if err != nil {
+		return nil, err
+	}
+
+	privkey := ed25519.NewKeyFromSeed(seed)
+
+	entry := &localKeystoreEntry{
+		Version:   version,
+		PublicKey: privkey.Public().(ed25519.PublicKey),
+		Seed:      encrypted,
+		CreatedAt: time.Now(),
+	}
+
+	store := localKeystore{
+		Versions: append(slices.Clone(svc.store.Versions), entry),
+	}
+
+	bs, err := json.MarshalIndent(&store, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(svc.path), 0700); err != nil {
+		return nil, err
+	}
+
+	tmp := svc.path + ".tmp"
+	if err := os.WriteFile(tmp, bs, 0600); err != nil {
+		return nil, err
+	}
+
+	if err := os.Rename(tmp, svc.path); err != nil {
+		return nil, err
+	}
+
+	key := &localKey{version, privkey}
+
+	svc.store = store
+	svc.keys[version] = key
+	svc.latest = version
+
+	return key, nil
+}
+
+func (svc *localKeyService) Key(ctx context.Context, v ...int) (Key, error) {
+	svc.RLock()
+	defer svc.RUnlock()
+
+	if len(svc.keys) == 0 {
+		return nil, errors.New("key empty")
+	}
+
+	ver := svc.latest
+	if len(v) > 0 {
+		ver = v[0]
+	}
+
+	if ver < 1 {
+		return nil, errors.New("invalid version")
+	}
+
+	key, ok := svc.keys[ver]
+	if !ok {
+		return nil, errors.New("key not found")
+	}
+
+	return key, nil
+}
+
+func (svc *localKeyService) Signature(ctx context.Context, data []byte, ver ...int) ([]byte, error) {
+	key, err := svc.Key(ctx, ver...)
+	if err != nil {
+		return nil, err
+	}
+
+	return key.Signature(ctx, data)
+}
+
+func (svc *localKeyService) Verify(ctx context.Context, data []byte, sig []byte, ver ...int) (bool, error) {
+	key, err := svc.Key(ctx, ver...)
+	if err != nil {
+		return false, err
+	}
+
+	return key.Verify(data, sig)
+}
+
+func (svc *localKeyService) Close() error {
+	svc.Lock()
+	defer svc.Unlock()
+
+	for _, key := range svc.keys {
+		clear(key.privkey)
+	}
+
+	svc.keys = make(map[int]*localKey)
+
+	return nil
+}
+
+type localKey struct {
+	version int
+	privkey ed25519.PrivateKey
+}
+
+func (key *localKey) Public() crypto.PublicKey {
+	return key.privkey.Public()
+}
+
+func (key *localKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) (signature []byte, err error) {
+	return key.privkey.Sign(rand, digest, opts)
+}
+
+func (key *localKey) Signature(ctx context.Context, data []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return key.Sign(rand.Reader, data, crypto.Hash(0))
+}
+
+func (key *localKey) Verify(data []byte, sig []byte) (bool, error) {
+	pubkey, ok := key.privkey.Public().(ed25519.PublicKey)
+	if !ok {
+		return false, errors.New("invalid key")
+	}
+
+	return ed25519.Verify(pubkey, data, sig), nil
+}
+
+func (key *localKey) Version() int {
+	return key.version
+}
@@ -0,0 +1,93 @@
+package keys
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flarexio/wallet/conf"
+)
+
+func TestLocalKeyService(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	cfg := &conf.LocalKeyConfig{
+		Name:       "keystore.json",
+		Path:       t.TempDir(),
+		Passphrase: "passphrase",
+	}
+
+	svc, err := NewLocalKeysService(cfg)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	key, err := svc.Key(ctx)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(1, key.Version())
+
+	data := []byte("test")
+
+	sig, err := key.Signature(ctx, data)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(sig, 64)
+	assert.True(key.Verify(data, sig))
+
+	svc.Close()
+
+	ver, err := RotateLocalKey(cfg)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(2, ver)
+
+	svc, err = NewLocalKeysService(cfg)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer svc.Close()
+
+	latest, err := svc.Key(ctx)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(2, latest.Version())
+
+	// signatures are deterministic per version
+	sig1, err := svc.Signature(ctx, data, 1)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(sig, sig1)
+	assert.True(svc.Verify(ctx, data, sig1, 1))
+	assert.False(svc.Verify(ctx, data, sig1, 2))
+
+	_, err = svc.Key(ctx, 3)
+	assert.Error(err)
+
+	_, err = svc.Key(ctx, 0)
+	assert.Error(err)
+
+	cfg.Passphrase = "wrong"
+	_, err = NewLocalKeysService(cfg)
+	assert.ErrorIs(err, ErrInvalidPassphrase)
+}
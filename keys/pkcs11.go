@@ -0,0 +1,336 @@
+//go:build pkcs11 && cgo
+
+package keys
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+
+	"github.com/flarexio/wallet/conf"
+)
+
+const (
+	ckkECEdwards = 0x00000040
+	ckmEdDSA     = 0x00001057
+)
+
+// NewPKCS11KeysService opens the token and maps every Ed25519 key pair
+// labeled cfg.Label onto a key version, taken from its CKA_ID.
+func NewPKCS11KeysService(cfg *conf.PKCS11KeyConfig) (Service, error) {
+	if cfg == nil {
+		return nil, errors.New("pkcs11 key config is required")
+	}
+
+	ctx := pkcs11.New(cfg.Library)
+	if ctx == nil {
+		return nil, errors.New("unable to load pkcs11 library")
+	}
+
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, err
+	}
+
+	svc := &pkcs11KeyService{
+		ctx:   ctx,
+		label: cfg.Label,
+	}
+
+	if err := svc.open(cfg.Token, cfg.PIN); err != nil {
+		svc.Close()
+		return nil, err
+	}
+
+	if err := svc.refresh(); err != nil {
+		svc.Close()
+		return nil, err
+	}
+
+	return svc, nil
+}
+
+type pkcs11KeyService struct {
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+	opened  bool
+	label   string
+	keys    map[int]*pkcs11Key
+	latest  int
+	sync.Mutex
+}
+
+func (svc *pkcs11KeyService) open(token string, pin string) error {
+	slots, err := svc.ctx.GetSlotList(true)
+	if err != nil {
+		return err
+	}
+
+	for _, slot := range slots {
+		info, err := svc.ctx.GetTokenInfo(slot)
+		if err != nil {
+			return err
+		}
+
+		if info.Label != token {
+			continue
+		}
+
+		session, err := svc.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+		if err != nil {
+			return err
+		}
+
+		svc.session = session
+		svc.opened = true
+
+		return svc.ctx.Login(session, pkcs11.CKU_USER, pin)
+	}
+
+	return errors.New("token not found")
+}
+
+func (svc *pkcs11KeyService) findObjects(template []*pkcs11.Attribute) ([]pkcs11.ObjectHandle, error) {
+	if err := svc.ctx.FindObjectsInit(svc.session, template); err != nil {
+		return nil, err
+	}
+	defer svc.ctx.FindObjectsFinal(svc.session)
+
+	handles := make([]pkcs11.ObjectHandle, 0)
+	for {
+		objs, _, err := svc.ctx.FindObjects(svc.session, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(objs) == 0 {
+			return handles, nil
+		}
+
+		handles = append(handles, objs...)
+	}
+}
+
+func (svc *pkcs11KeyService) refresh() error {
+	svc.Lock()
+	defer svc.Unlock()
+
+	pubs, err := svc.findObjects([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, ckkECEdwards),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, svc.label),
+	})
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[int]*pkcs11Key)
+	latest := 0
+	for _, pub := range pubs {
+		attrs, err := svc.ctx.GetAttributeValue(svc.session, pub, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_ID, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+		})
+		if err != nil {
+			return err
+		}
+
+		id := attrs[0].Value
+		ver := int(new(big.Int).SetBytes(id).Int64())
+		if ver < 1 {
+			continue
+		}
+
+		pubkey, err := parseECPoint(attrs[1].Value)
+		if err != nil {
+			return err
+		}
+
+		privs, err := svc.findObjects([]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, ckkECEdwards),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, svc.label),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(privs) != 1 {
+			return errors.New("private key not found")
+		}
+
+		if _, ok := keys[ver]; ok {
+			return errors.New("duplicate key version")
+		}
+
+		keys[ver] = &pkcs11Key{
+			svc:     svc,
+			handle:  privs[0],
+			version: ver,
+			pubkey:  pubkey,
+		}
+
+		latest = max(latest, ver)
+	}
+
+	svc.keys = keys
+	svc.latest = latest
+
+	return nil
+}
+
+// parseECPoint accepts both the DER-wrapped and the raw CKA_EC_POINT encoding.
+func parseECPoint(point []byte) (ed25519.PublicKey, error) {
+	if len(point) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(point), nil
+	}
+
+	var raw []byte
+	if _, err := asn1.Unmarshal(point, &raw); err != nil {
+		return nil, err
+	}
+
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid public key")
+	}
+
+	return ed25519.PublicKey(raw), nil
+}
+
+func (svc *pkcs11KeyService) lookup(v ...int) (*pkcs11Key, error) {
+	svc.Lock()
+	defer svc.Unlock()
+
+	if len(svc.keys) == 0 {
+		return nil, errors.New("key empty")
+	}
+
+	ver := svc.latest
+	if len(v) > 0 {
+		ver = v[0]
+	}
+
+	if ver < 1 {
+		return nil, errors.New("invalid version")
+	}
+
+	key, ok := svc.keys[ver]
+	if !ok {
+		return nil, errors.New("key not found")
+	}
+
+	return key, nil
+}
+
+func (svc *pkcs11KeyService) Key(ctx context.Context, v ...int) (Key, error) {
+	key, err := svc.lookup(v...)
+	if err == nil {
+		return key, nil
+	}
+
+	// new key versions may have been provisioned since the last refresh
+	if err := svc.refresh(); err != nil {
+		return nil, err
+	}
+
+	return svc.lookup(v...)
+}
+
+func (svc *pkcs11KeyService) Signature(ctx context.Context, data []byte, ver ...int) ([]byte, error) {
+	key, err := svc.Key(ctx, ver...)
+	if err != nil {
+		return nil, err
+	}
+
+	return key.Signature(ctx, data)
+}
+
+func (svc *pkcs11KeyService) Verify(ctx context.Context, data []byte, sig []byte, ver ...int) (bool, error) {
+	key, err := svc.Key(ctx, ver...)
+	if err != nil {
+		return false, err
+	}
+
+	return key.Verify(data, sig)
+}
+
+func (svc *pkcs11KeyService) Close() error {
+	svc.Lock()
+	defer svc.Unlock()
+
+	if svc.ctx == nil {
+		return nil
+	}
+
+	if svc.opened {
+		svc.ctx.Logout(svc.session)
+		svc.ctx.CloseSession(svc.session)
+	}
+
+	err := svc.ctx.Finalize()
+	svc.ctx.Destroy()
+	svc.ctx = nil
+
+	return err
+}
+
+func (svc *pkcs11KeyService) sign(handle pkcs11.ObjectHandle, data []byte) ([]byte, error) {
+	svc.Lock()
+	defer svc.Unlock()
+
+	if svc.ctx == nil {
+		return nil, errors.New("service closed")
+	}
+
+	mech := []*pkcs11.Mechanism{pkcs11.NewMechanism(ckmEdDSA, nil)}
+	if err := svc.ctx.SignInit(svc.session, mech, handle); err != nil {
+		return nil, err
+	}
+
+	return svc.ctx.Sign(svc.session, data)
+}
+
+type pkcs11Key struct {
+	svc     *pkcs11KeyService
+	handle  pkcs11.ObjectHandle
+	version int
+	pubkey  ed25519.PublicKey
+}
+
+func (key *pkcs11Key) Public() crypto.PublicKey {
+	return key.pubkey
+}
+
+func (key *pkcs11Key) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) (signature []byte, err error) {
+	if opts != nil && opts.HashFunc() != crypto.Hash(0) {
+		return nil, errors.New("unsupported hash function")
+	}
+
+	return key.svc.sign(key.handle, digest)
+}
+
+func (key *pkcs11Key) Signature(ctx context.Context, data []byte) ([]byte, error) {
+	// PKCS#11 calls are blocking; honor cancellation before taking the session.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return key.Sign(rand.Reader, data, crypto.Hash(0))
+}
+
+func (key *pkcs11Key) Verify(data []byte, sig []byte) (bool, error) {
+	return ed25519.Verify(key.pubkey, data, sig), nil
+}
+
+func (key *pkcs11Key) Version() int {
+	return key.version
+}
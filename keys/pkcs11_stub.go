@@ -0,0 +1,13 @@
+//go:build !pkcs11 || !cgo
+
+package keys
+
+import (
+	"errors"
+
+	"github.com/flarexio/wallet/conf"
+)
+
+func NewPKCS11KeysService(cfg *conf.PKCS11KeyConfig) (Service, error) {
+	return nil, errors.New("pkcs11 support requires a cgo build with -tags pkcs11")
+}
@@ -0,0 +1,72 @@
+//go:build pkcs11 && cgo
+
+package keys
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flarexio/wallet/conf"
+)
+
+// Provision a SoftHSM2 token before running:
+//
+//	softhsm2-util --init-token --free --label wallet --so-pin 0000 --pin 1234
+//	pkcs11-tool --module $SOFTHSM2_LIB --token-label wallet --login --pin 1234 \
+//	  --keypairgen --key-type EC:edwards25519 --label wallet --id 01
+func TestPKCS11KeyService(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	lib, ok := os.LookupEnv("SOFTHSM2_LIB")
+	if !ok {
+		t.Skip(`"SOFTHSM2_LIB" is not set`)
+		return
+	}
+
+	cfg := &conf.PKCS11KeyConfig{
+		Library: lib,
+		Token:   "wallet",
+		PIN:     "1234",
+		Label:   "wallet",
+	}
+
+	svc, err := NewPKCS11KeysService(cfg)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer svc.Close()
+
+	key, err := svc.Key(ctx, 1)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(1, key.Version())
+
+	data := []byte("test")
+
+	sig, err := key.Signature(ctx, data)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(sig, 64)
+	assert.True(key.Verify(data, sig))
+
+	// Ed25519 is deterministic, so account derivation is stable across calls
+	sig2, err := svc.Signature(ctx, data, 1)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(sig, sig2)
+}
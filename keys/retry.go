@@ -0,0 +1,86 @@
+package keys
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/flarexio/wallet/conf"
+)
+
+const (
+	defaultTimeout    = 10 * time.Second
+	defaultAttempts   = 3
+	defaultBackoff    = 100 * time.Millisecond
+	defaultMaxBackoff = 2 * time.Second
+)
+
+// retryPolicy bounds every remote key operation with a per-attempt deadline
+// and retries transient failures with exponential backoff.
+type retryPolicy struct {
+	timeout    time.Duration
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+func newRetryPolicy(timeout time.Duration, cfg conf.RetryConfig) retryPolicy {
+	p := retryPolicy{
+		timeout:    timeout,
+		attempts:   cfg.Attempts,
+		backoff:    cfg.Backoff,
+		maxBackoff: cfg.MaxBackoff,
+	}
+
+	if p.timeout <= 0 {
+		p.timeout = defaultTimeout
+	}
+
+	if p.attempts <= 0 {
+		p.attempts = defaultAttempts
+	}
+
+	if p.backoff <= 0 {
+		p.backoff = defaultBackoff
+	}
+
+	if p.maxBackoff <= 0 {
+		p.maxBackoff = defaultMaxBackoff
+	}
+
+	return p
+}
+
+func (p retryPolicy) delay(attempt int) time.Duration {
+	d := p.backoff << (attempt - 1)
+	if d <= 0 || d > p.maxBackoff {
+		d = p.maxBackoff
+	}
+
+	// full jitter on the upper half avoids synchronized retries
+	return d/2 + rand.N(d/2+1)
+}
+
+func (p retryPolicy) do(ctx context.Context, transient func(error) bool, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 0; attempt < p.attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+
+			case <-time.After(p.delay(attempt)):
+			}
+		}
+
+		callCtx, cancel := context.WithTimeout(ctx, p.timeout)
+		err = fn(callCtx)
+		cancel()
+
+		if err == nil || ctx.Err() != nil || !transient(err) {
+			return err
+		}
+	}
+
+	return err
+}
@@ -1,17 +1,47 @@
 package keys
 
-import "crypto"
+import (
+	"context"
+	"crypto"
+	"errors"
+
+	"github.com/flarexio/wallet/conf"
+)
+
+var (
+	ErrKeyVersionDisabled  = errors.New("key version disabled")
+	ErrKeyVersionDestroyed = errors.New("key version destroyed")
+)
 
 type Service interface {
-	Key(ver ...int) (Key, error)
-	Signature(data []byte, ver ...int) ([]byte, error)
-	Verify(data []byte, sig []byte, ver ...int) (bool, error)
+	Key(ctx context.Context, ver ...int) (Key, error)
+	Signature(ctx context.Context, data []byte, ver ...int) ([]byte, error)
+	Verify(ctx context.Context, data []byte, sig []byte, ver ...int) (bool, error)
 	Close() error
 }
 
 type Key interface {
 	crypto.Signer
-	Signature(data []byte) ([]byte, error)
+	Signature(ctx context.Context, data []byte) ([]byte, error)
 	Verify(data []byte, sig []byte) (bool, error)
 	Version() int
 }
+
+func NewKeysService(cfg conf.KeyConfig) (Service, error) {
+	switch cfg.Driver {
+	case conf.KeyDriverGoogle:
+		return NewGoogleKeysService(cfg.Google)
+
+	case conf.KeyDriverLocal:
+		return NewLocalKeysService(cfg.Local)
+
+	case conf.KeyDriverVault:
+		return NewVaultKeysService(cfg.Vault)
+
+	case conf.KeyDriverPKCS11:
+		return NewPKCS11KeysService(cfg.PKCS11)
+
+	default:
+		return nil, errors.New("invalid key driver")
+	}
+}
@@ -1,9 +1,11 @@
 package keys
 
 import (
+	"context"
 	"os"
 	"testing"
 
+	"cloud.google.com/go/kms/apiv1/kmspb"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v3"
 
@@ -13,6 +15,8 @@ import (
 func TestGoogleKeyService(t *testing.T) {
 	assert := assert.New(t)
 
+	ctx := context.Background()
+
 	f, err := os.Open("../config.example.yaml")
 	if err != nil {
 		assert.Fail(err.Error())
@@ -39,7 +43,7 @@ func TestGoogleKeyService(t *testing.T) {
 	}
 	defer svc.Close()
 
-	key, err := svc.Key()
+	key, err := svc.Key(ctx)
 	if err != nil {
 		assert.Fail(err.Error())
 		return
@@ -49,7 +53,7 @@ func TestGoogleKeyService(t *testing.T) {
 
 	data := []byte("test")
 
-	sig, err := key.Signature(data)
+	sig, err := key.Signature(ctx, data)
 	if err != nil {
 		assert.Fail(err.Error())
 		return
@@ -58,3 +62,51 @@ func TestGoogleKeyService(t *testing.T) {
 	assert.Len(sig, 64)
 	assert.True(key.Verify(data, sig))
 }
+
+func TestGoogleKeyVersions(t *testing.T) {
+	assert := assert.New(t)
+
+	path := "projects/p/locations/global/keyRings/wallet/cryptoKeys/main/cryptoKeyVersions/"
+
+	versions, latest := indexKeyVersions([]*kmspb.CryptoKeyVersion{
+		{Name: path + "1", State: kmspb.CryptoKeyVersion_DESTROYED},
+		{Name: path + "2", State: kmspb.CryptoKeyVersion_ENABLED},
+		{Name: path + "4", State: kmspb.CryptoKeyVersion_DISABLED},
+		{Name: path + "3", State: kmspb.CryptoKeyVersion_ENABLED},
+		{Name: path + "5", State: kmspb.CryptoKeyVersion_PENDING_GENERATION},
+	})
+
+	assert.Len(versions, 5)
+	assert.Equal(3, latest)
+
+	version, err := lookupKeyVersion(versions, latest)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(path+"3", version.Name)
+
+	version, err = lookupKeyVersion(versions, latest, 2)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(path+"2", version.Name)
+
+	_, err = lookupKeyVersion(versions, latest, 1)
+	assert.ErrorIs(err, ErrKeyVersionDestroyed)
+
+	_, err = lookupKeyVersion(versions, latest, 4)
+	assert.ErrorIs(err, ErrKeyVersionDisabled)
+
+	_, err = lookupKeyVersion(versions, latest, 5)
+	assert.Error(err)
+
+	_, err = lookupKeyVersion(versions, latest, 6)
+	assert.Error(err)
+
+	_, err = lookupKeyVersion(map[int]*kmspb.CryptoKeyVersion{}, 0)
+	assert.Error(err)
+}
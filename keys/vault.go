@@ -0,0 +1,325 @@
+package keys
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/flarexio/wallet/conf"
+)
+
+func NewVaultKeysService(cfg *conf.VaultKeyConfig) (Service, error) {
+	if cfg == nil {
+		return nil, errors.New("vault key config is required")
+	}
+
+	if cfg.Address == "" || cfg.Key == "" {
+		return nil, errors.New("vault address and key are required")
+	}
+
+	client := &vaultClient{
+		client:  &http.Client{},
+		policy:  newRetryPolicy(cfg.Timeout, cfg.Retry),
+		address: strings.TrimSuffix(cfg.Address, "/"),
+		token:   cfg.Token,
+		mount:   strings.Trim(cfg.Mount, "/"),
+		key:     cfg.Key,
+	}
+
+	svc := &vaultKeyService{client: client}
+	if err := svc.refresh(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return svc, nil
+}
+
+type vaultKeyService struct {
+	client  *vaultClient
+	pubkeys map[int]ed25519.PublicKey
+	latest  int
+	sync.RWMutex
+}
+
+func (svc *vaultKeyService) refresh(ctx context.Context) error {
+	info, err := svc.client.readKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	if info.Type != "ed25519" {
+		return errors.New("unsupported algorithm")
+	}
+
+	pubkeys := make(map[int]ed25519.PublicKey)
+	for v, k := range info.Keys {
+		ver, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+
+		pub, err := base64.StdEncoding.DecodeString(k.PublicKey)
+		if err != nil {
+			return err
+		}
+
+		if len(pub) != ed25519.PublicKeySize {
+			return errors.New("invalid public key")
+		}
+
+		pubkeys[ver] = ed25519.PublicKey(pub)
+	}
+
+	svc.Lock()
+	svc.pubkeys = pubkeys
+	svc.latest = info.LatestVersion
+	svc.Unlock()
+
+	return nil
+}
+
+func (svc *vaultKeyService) lookup(v ...int) (int, ed25519.PublicKey, error) {
+	svc.RLock()
+	defer svc.RUnlock()
+
+	if len(svc.pubkeys) == 0 {
+		return 0, nil, errors.New("key empty")
+	}
+
+	ver := svc.latest
+	if len(v) > 0 {
+		ver = v[0]
+	}
+
+	if ver < 1 {
+		return 0, nil, errors.New("invalid version")
+	}
+
+	pubkey, ok := svc.pubkeys[ver]
+	if !ok {
+		return ver, nil, errors.New("key not found")
+	}
+
+	return ver, pubkey, nil
+}
+
+func (svc *vaultKeyService) Key(ctx context.Context, v ...int) (Key, error) {
+	ver, pubkey, err := svc.lookup(v...)
+	if err != nil {
+		// the key may have been rotated since the last refresh
+		if ver <= 0 {
+			return nil, err
+		}
+
+		if err := svc.refresh(ctx); err != nil {
+			return nil, err
+		}
+
+		ver, pubkey, err = svc.lookup(v...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &vaultKey{
+		client:  svc.client,
+		version: ver,
+		pubkey:  pubkey,
+	}, nil
+}
+
+func (svc *vaultKeyService) Signature(ctx context.Context, data []byte, ver ...int) ([]byte, error) {
+	key, err := svc.Key(ctx, ver...)
+	if err != nil {
+		return nil, err
+	}
+
+	return key.Signature(ctx, data)
+}
+
+func (svc *vaultKeyService) Verify(ctx context.Context, data []byte, sig []byte, ver ...int) (bool, error) {
+	key, err := svc.Key(ctx, ver...)
+	if err != nil {
+		return false, err
+	}
+
+	return key.Verify(data, sig)
+}
+
+func (svc *vaultKeyService) Close() error {
+	svc.client.client.CloseIdleConnections()
+	return nil
+}
+
+type vaultKey struct {
+	client  *vaultClient
+	version int
+	pubkey  ed25519.PublicKey
+}
+
+func (key *vaultKey) Public() crypto.PublicKey {
+	return key.pubkey
+}
+
+func (key *vaultKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) (signature []byte, err error) {
+	if opts != nil && opts.HashFunc() != crypto.Hash(0) {
+		return nil, errors.New("unsupported hash function")
+	}
+
+	return key.Signature(context.Background(), digest)
+}
+
+func (key *vaultKey) Signature(ctx context.Context, data []byte) ([]byte, error) {
+	return key.client.sign(ctx, data, key.version)
+}
+
+func (key *vaultKey) Verify(data []byte, sig []byte) (bool, error) {
+	return ed25519.Verify(key.pubkey, data, sig), nil
+}
+
+func (key *vaultKey) Version() int {
+	return key.version
+}
+
+type vaultClient struct {
+	client  *http.Client
+	policy  retryPolicy
+	address string
+	token   string
+	mount   string
+	key     string
+}
+
+type vaultKeyInfo struct {
+	Type          string `json:"type"`
+	LatestVersion int    `json:"latest_version"`
+	Keys          map[string]struct {
+		PublicKey    string `json:"public_key"`
+		CreationTime string `json:"creation_time"`
+	} `json:"keys"`
+}
+
+func (c *vaultClient) readKey(ctx context.Context) (*vaultKeyInfo, error) {
+	var info *vaultKeyInfo
+	if err := c.do(ctx, http.MethodGet, "/keys/"+c.key, nil, &info); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+func (c *vaultClient) sign(ctx context.Context, data []byte, version int) ([]byte, error) {
+	req := map[string]any{
+		"input":       base64.StdEncoding.EncodeToString(data),
+		"key_version": version,
+	}
+
+	var resp struct {
+		Signature string `json:"signature"`
+	}
+
+	if err := c.do(ctx, http.MethodPost, "/sign/"+c.key, req, &resp); err != nil {
+		return nil, err
+	}
+
+	// vault:v<version>:<base64 signature>
+	parts := strings.SplitN(resp.Signature, ":", 3)
+	if len(parts) != 3 || parts[0] != "vault" {
+		return nil, errors.New("invalid signature format")
+	}
+
+	if parts[1] != "v"+strconv.Itoa(version) {
+		return nil, errors.New("invalid key version")
+	}
+
+	return base64.StdEncoding.DecodeString(parts[2])
+}
+
+// vaultError is returned for non-2xx responses from Vault.
+type vaultError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *vaultError) Error() string {
+	return "vault: " + e.Message
+}
+
+func isVaultTransient(err error) bool {
+	var verr *vaultError
+	if errors.As(err, &verr) {
+		return verr.StatusCode == http.StatusTooManyRequests || verr.StatusCode >= 500
+	}
+
+	// network failures and per-attempt deadlines
+	return true
+}
+
+func (c *vaultClient) do(ctx context.Context, method string, path string, in any, out any) error {
+	var bs []byte
+	if in != nil {
+		var err error
+		bs, err = json.Marshal(in)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.policy.do(ctx, isVaultTransient, func(ctx context.Context) error {
+		return c.call(ctx, method, path, bs, out)
+	})
+}
+
+func (c *vaultClient) call(ctx context.Context, method string, path string, in []byte, out any) error {
+	var body io.Reader
+	if in != nil {
+		body = bytes.NewReader(in)
+	}
+
+	url := c.address + "/v1/" + c.mount + path
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-Vault-Token", c.token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []string        `json:"errors"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return &vaultError{resp.StatusCode, resp.Status}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		msg := resp.Status
+		if len(result.Errors) > 0 {
+			msg = strings.Join(result.Errors, "; ")
+		}
+
+		return &vaultError{resp.StatusCode, msg}
+	}
+
+	return json.Unmarshal(result.Data, out)
+}
@@ -0,0 +1,205 @@
+package keys
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flarexio/wallet/conf"
+)
+
+// fakeTransit implements the subset of the Vault Transit API used by vaultKeyService.
+type fakeTransit struct {
+	token    string
+	keys     []ed25519.PrivateKey
+	failures int
+	sync.Mutex
+}
+
+func (f *fakeTransit) rotate() {
+	f.Lock()
+	defer f.Unlock()
+
+	_, privkey, _ := ed25519.GenerateKey(nil)
+	f.keys = append(f.keys, privkey)
+}
+
+func (f *fakeTransit) fail(n int) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.failures = n
+}
+
+func (f *fakeTransit) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	if r.Header.Get("X-Vault-Token") != f.token {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]any{"errors": []string{"permission denied"}})
+		return
+	}
+
+	if f.failures > 0 {
+		f.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]any{"errors": []string{"unavailable"}})
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/transit/keys/wallet":
+		keys := make(map[string]any)
+		for i, k := range f.keys {
+			keys[strconv.Itoa(i+1)] = map[string]any{
+				"public_key": base64.StdEncoding.EncodeToString(k.Public().(ed25519.PublicKey)),
+			}
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{
+				"type":           "ed25519",
+				"latest_version": len(f.keys),
+				"keys":           keys,
+			},
+		})
+
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/transit/sign/wallet":
+		var req struct {
+			Input      string `json:"input"`
+			KeyVersion int    `json:"key_version"`
+		}
+
+		json.NewDecoder(r.Body).Decode(&req)
+
+		input, _ := base64.StdEncoding.DecodeString(req.Input)
+		sig := ed25519.Sign(f.keys[req.KeyVersion-1], input)
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{
+				"signature": "vault:v" + strconv.Itoa(req.KeyVersion) + ":" + base64.StdEncoding.EncodeToString(sig),
+			},
+		})
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]any{"errors": []string{}})
+	}
+}
+
+func TestVaultKeyService(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	fake := &fakeTransit{token: "root"}
+	fake.rotate()
+
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	cfg := &conf.VaultKeyConfig{
+		Address: server.URL,
+		Token:   "root",
+		Mount:   "transit",
+		Key:     "wallet",
+	}
+
+	// Run against a local dev server instead: vault server -dev
+	// vault secrets enable transit && vault write -f transit/keys/wallet type=ed25519
+	if addr, ok := os.LookupEnv("VAULT_ADDR"); ok {
+		cfg.Address = addr
+		cfg.Token = os.Getenv("VAULT_TOKEN")
+	}
+
+	svc, err := NewVaultKeysService(cfg)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer svc.Close()
+
+	key, err := svc.Key(ctx)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	data := []byte("test")
+
+	sig, err := key.Signature(ctx, data)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(sig, 64)
+	assert.True(key.Verify(data, sig))
+
+	if _, ok := os.LookupEnv("VAULT_ADDR"); ok {
+		return
+	}
+
+	assert.Equal(1, key.Version())
+
+	fake.rotate()
+
+	// new versions are discovered on demand
+	key2, err := svc.Key(ctx, 2)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(2, key2.Version())
+
+	sig2, err := key2.Signature(ctx, data)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.NotEqual(sig, sig2)
+
+	// transient failures are retried
+	fake.fail(2)
+
+	sig3, err := key2.Signature(ctx, data)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(sig2, sig3)
+
+	fake.fail(3)
+
+	_, err = key2.Signature(ctx, data)
+	assert.ErrorContains(err, "unavailable")
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err = key2.Signature(cancelled, data)
+	assert.ErrorIs(err, context.Canceled)
+	assert.True(svc.Verify(ctx, data, sig, 1))
+	assert.False(svc.Verify(ctx, data, sig, 2))
+
+	_, err = svc.Key(ctx, 3)
+	assert.Error(err)
+
+	cfg.Address = server.URL
+	cfg.Token = "invalid"
+	_, err = NewVaultKeysService(cfg)
+	assert.ErrorContains(err, "permission denied")
+}
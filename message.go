@@ -0,0 +1,76 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/go-webauthn/webauthn/protocol"
+
+	"github.com/flarexio/wallet/offchain"
+)
+
+var ErrMessageIsTransaction = errors.New("message is a transaction")
+
+// checkMessage guards raw message signing. A raw message must not decode as a
+// transaction, and an off-chain envelope must be well-formed and name the
+// signing wallet.
+func checkMessage(wallet solana.PublicKey, message []byte) error {
+	if offchain.IsEnvelope(message) {
+		m, err := offchain.Parse(message)
+		if err != nil {
+			return err
+		}
+
+		if !m.HasSigner(wallet) {
+			return fmt.Errorf("%w: wallet is not a signer", offchain.ErrInvalidMessage)
+		}
+
+		return nil
+	}
+
+	if offchain.IsTransactionMessage(message) {
+		return ErrMessageIsTransaction
+	}
+
+	return nil
+}
+
+// InitializeSignOffchainMessage wraps the message in an off-chain envelope
+// signed by the wallet and hands it to the passkey-gated message flow;
+// FinalizeSignMessage completes it.
+func (svc *service) InitializeSignOffchainMessage(ctx context.Context, req *InitializeSignOffchainMessageRequest) (*protocol.CredentialAssertion, string, *offchain.Message, error) {
+	a, err := svc.findActive(req.Subject)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	w, err := a.FindWallet(req.Wallet)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	m, err := offchain.New(req.ApplicationDomain, []solana.PublicKey{w.PublicKey}, []byte(req.Message))
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	bs, err := m.MarshalBinary()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	opts, mediation, err := svc.InitializeSignMessage(ctx, &InitializeSignMessageRequest{
+		Subject:       req.Subject,
+		UserID:        req.UserID,
+		TransactionID: req.TransactionID,
+		Wallet:        req.Wallet,
+		Message:       bs,
+	})
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return opts, mediation, m, nil
+}
@@ -0,0 +1,80 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flarexio/wallet/offchain"
+)
+
+func TestSignMessageGuard(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	from, err := svc.Wallet(ctx, "user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(1_000, from, solana.NewWallet().PublicKey()).Build(),
+		},
+		solana.Hash{1},
+		solana.TransactionPayer(from),
+	)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	msg, err := tx.Message.MarshalBinary()
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	_, err = svc.SignMessage(ctx, "user", 0, msg)
+	assert.ErrorIs(err, ErrMessageIsTransaction)
+
+	envelope, err := offchain.New([32]byte{}, []solana.PublicKey{from}, []byte("hello"))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	bs, err := envelope.MarshalBinary()
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	sig, err := svc.SignMessage(ctx, "user", 0, bs)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.True(ed25519.Verify(from[:], bs, sig[:]))
+
+	// an envelope naming another signer is refused
+	envelope.Signers = []solana.PublicKey{solana.NewWallet().PublicKey()}
+
+	bs, err = envelope.MarshalBinary()
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	_, err = svc.SignMessage(ctx, "user", 0, bs)
+	assert.ErrorIs(err, offchain.ErrInvalidMessage)
+}
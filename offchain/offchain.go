@@ -0,0 +1,309 @@
+// Package offchain implements the Solana off-chain message envelope, which
+// domain-separates signed messages from transactions.
+package offchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+var ErrInvalidMessage = errors.New("invalid off-chain message")
+
+// SigningDomain prefixes every envelope. Its first byte can never start a
+// valid transaction message.
+var SigningDomain = []byte("\xffsolana offchain")
+
+const Version0 uint8 = 0
+
+const (
+	// formats 0 and 1 fit in a single hardware wallet packet, header included
+	maxLedgerMessageSize = 1232
+	maxMessageSize       = 65535
+	maxSigners           = 255
+)
+
+type Format uint8
+
+const (
+	FormatRestrictedASCII Format = iota
+	FormatLimitedUTF8
+	FormatExtendedUTF8
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatRestrictedASCII:
+		return "restricted-ascii"
+	case FormatLimitedUTF8:
+		return "limited-utf8"
+	case FormatExtendedUTF8:
+		return "extended-utf8"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(f))
+	}
+}
+
+// Message is a version 0 envelope:
+//
+//	signing domain (16) | version (1) | application domain (32) |
+//	format (1) | signer count (1) | signers (32 each) | length (2, LE) | body
+type Message struct {
+	Version           uint8              `json:"version"`
+	ApplicationDomain [32]byte           `json:"application_domain"`
+	Format            Format             `json:"format"`
+	Signers           []solana.PublicKey `json:"signers"`
+	Body              []byte             `json:"body"`
+}
+
+// New wraps body in an envelope using the most restrictive format it fits.
+func New(domain [32]byte, signers []solana.PublicKey, body []byte) (*Message, error) {
+	m := &Message{
+		Version:           Version0,
+		ApplicationDomain: domain,
+		Signers:           signers,
+		Body:              body,
+	}
+
+	size := m.headerSize() + len(body)
+
+	switch {
+	case isRestrictedASCII(body) && size <= maxLedgerMessageSize:
+		m.Format = FormatRestrictedASCII
+
+	case utf8.Valid(body) && size <= maxLedgerMessageSize:
+		m.Format = FormatLimitedUTF8
+
+	default:
+		m.Format = FormatExtendedUTF8
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func isRestrictedASCII(body []byte) bool {
+	for _, b := range body {
+		if b < 0x20 || b > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m *Message) headerSize() int {
+	return len(SigningDomain) + 1 + 32 + 1 + 1 + 32*len(m.Signers) + 2
+}
+
+func (m *Message) Validate() error {
+	if m.Version != Version0 {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidMessage, m.Version)
+	}
+
+	if len(m.Signers) == 0 || len(m.Signers) > maxSigners {
+		return fmt.Errorf("%w: signer count", ErrInvalidMessage)
+	}
+
+	for i, signer := range m.Signers {
+		for _, other := range m.Signers[:i] {
+			if signer.Equals(other) {
+				return fmt.Errorf("%w: duplicate signer %s", ErrInvalidMessage, signer)
+			}
+		}
+	}
+
+	if len(m.Body) == 0 || len(m.Body) > maxMessageSize {
+		return fmt.Errorf("%w: message length", ErrInvalidMessage)
+	}
+
+	size := m.headerSize() + len(m.Body)
+
+	switch m.Format {
+	case FormatRestrictedASCII:
+		if !isRestrictedASCII(m.Body) {
+			return fmt.Errorf("%w: message is not printable ascii", ErrInvalidMessage)
+		}
+
+		if size > maxLedgerMessageSize {
+			return fmt.Errorf("%w: message too long for format", ErrInvalidMessage)
+		}
+
+	case FormatLimitedUTF8:
+		if !utf8.Valid(m.Body) {
+			return fmt.Errorf("%w: message is not utf-8", ErrInvalidMessage)
+		}
+
+		if size > maxLedgerMessageSize {
+			return fmt.Errorf("%w: message too long for format", ErrInvalidMessage)
+		}
+
+	case FormatExtendedUTF8:
+		if !utf8.Valid(m.Body) {
+			return fmt.Errorf("%w: message is not utf-8", ErrInvalidMessage)
+		}
+
+	default:
+		return fmt.Errorf("%w: unknown format %d", ErrInvalidMessage, m.Format)
+	}
+
+	return nil
+}
+
+// HasSigner reports whether key is one of the envelope's signers.
+func (m *Message) HasSigner(key solana.PublicKey) bool {
+	for _, signer := range m.Signers {
+		if signer.Equals(key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *Message) MarshalBinary() ([]byte, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, m.headerSize()+len(m.Body)))
+	buf.Write(SigningDomain)
+	buf.WriteByte(m.Version)
+	buf.Write(m.ApplicationDomain[:])
+	buf.WriteByte(byte(m.Format))
+	buf.WriteByte(byte(len(m.Signers)))
+
+	for _, signer := range m.Signers {
+		buf.Write(signer[:])
+	}
+
+	binary.Write(buf, binary.LittleEndian, uint16(len(m.Body)))
+	buf.Write(m.Body)
+
+	return buf.Bytes(), nil
+}
+
+func (m *Message) UnmarshalBinary(data []byte) error {
+	rest, ok := bytes.CutPrefix(data, SigningDomain)
+	if !ok {
+		return fmt.Errorf("%w: signing domain", ErrInvalidMessage)
+	}
+
+	// version, application domain, format and signer count
+	if len(rest) < 1+32+1+1 {
+		return fmt.Errorf("%w: truncated header", ErrInvalidMessage)
+	}
+
+	m.Version = rest[0]
+	copy(m.ApplicationDomain[:], rest[1:33])
+	m.Format = Format(rest[33])
+
+	count := int(rest[34])
+	rest = rest[35:]
+
+	if len(rest) < 32*count+2 {
+		return fmt.Errorf("%w: truncated signers", ErrInvalidMessage)
+	}
+
+	m.Signers = make([]solana.PublicKey, count)
+	for i := range m.Signers {
+		m.Signers[i] = solana.PublicKeyFromBytes(rest[:32])
+		rest = rest[32:]
+	}
+
+	length := int(binary.LittleEndian.Uint16(rest[:2]))
+	rest = rest[2:]
+
+	if len(rest) != length {
+		return fmt.Errorf("%w: length mismatch", ErrInvalidMessage)
+	}
+
+	m.Body = bytes.Clone(rest)
+
+	return m.Validate()
+}
+
+func Parse(data []byte) (*Message, error) {
+	var m Message
+	if err := m.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// IsEnvelope reports whether data claims to be an off-chain message.
+func IsEnvelope(data []byte) bool {
+	return bytes.HasPrefix(data, SigningDomain)
+}
+
+// IsTransactionMessage reports whether data decodes completely as a plausible
+// transaction message or signed transaction, i.e. whether signing it as a
+// "message" would in fact authorize a transaction.
+func IsTransactionMessage(data []byte) bool {
+	if len(data) == 0 || IsEnvelope(data) {
+		return false
+	}
+
+	var msg solana.Message
+	decoder := bin.NewBinDecoder(data)
+	if err := msg.UnmarshalWithDecoder(decoder); err == nil && decoder.Remaining() == 0 && plausible(&msg, data[0]) {
+		return true
+	}
+
+	decoder = bin.NewBinDecoder(data)
+	tx, err := solana.TransactionFromDecoder(decoder)
+	if err != nil || decoder.Remaining() != 0 || len(tx.Signatures) == 0 {
+		return false
+	}
+
+	bs, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return false
+	}
+
+	return plausible(&tx.Message, bs[0])
+}
+
+func plausible(msg *solana.Message, prefix byte) bool {
+	// only the v0 prefix is a valid versioned message today
+	if prefix >= 0x80 && prefix != 0x80 {
+		return false
+	}
+
+	keys := len(msg.AccountKeys)
+	for _, lookup := range msg.AddressTableLookups {
+		keys += len(lookup.WritableIndexes) + len(lookup.ReadonlyIndexes)
+	}
+
+	required := int(msg.Header.NumRequiredSignatures)
+	if required == 0 || required > len(msg.AccountKeys) {
+		return false
+	}
+
+	if int(msg.Header.NumReadonlySignedAccounts) >= required {
+		return false
+	}
+
+	for _, inst := range msg.Instructions {
+		if int(inst.ProgramIDIndex) >= keys {
+			return false
+		}
+
+		for _, account := range inst.Accounts {
+			if int(account) >= keys {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,135 @@
+package offchain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	var domain [32]byte
+	copy(domain[:], "example.com")
+
+	signer := solana.NewWallet().PublicKey()
+
+	m, err := New(domain, []solana.PublicKey{signer}, []byte("hello"))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(FormatRestrictedASCII, m.Format)
+
+	bs, err := m.MarshalBinary()
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.True(IsEnvelope(bs))
+	assert.Len(bs, 16+1+32+1+1+32+2+5)
+
+	parsed, err := Parse(bs)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(m, parsed)
+	assert.True(parsed.HasSigner(signer))
+
+	_, err = Parse(bs[:len(bs)-1])
+	assert.ErrorIs(err, ErrInvalidMessage)
+}
+
+func TestMessageFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	signers := []solana.PublicKey{solana.NewWallet().PublicKey()}
+
+	m, err := New([32]byte{}, signers, []byte("héllo"))
+	if assert.NoError(err) {
+		assert.Equal(FormatLimitedUTF8, m.Format)
+	}
+
+	m, err = New([32]byte{}, signers, []byte(strings.Repeat("a", 2000)))
+	if assert.NoError(err) {
+		assert.Equal(FormatExtendedUTF8, m.Format)
+	}
+
+	_, err = New([32]byte{}, signers, []byte{0xff, 0xfe})
+	assert.ErrorIs(err, ErrInvalidMessage)
+
+	_, err = New([32]byte{}, nil, []byte("hello"))
+	assert.ErrorIs(err, ErrInvalidMessage)
+
+	m = &Message{Signers: signers, Format: FormatRestrictedASCII, Body: []byte("line\nbreak")}
+	assert.ErrorIs(m.Validate(), ErrInvalidMessage)
+}
+
+func TestIsTransactionMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	wallet := solana.NewWallet()
+	from := wallet.PublicKey()
+
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(1_000, from, solana.NewWallet().PublicKey()).Build(),
+		},
+		solana.Hash{1},
+		solana.TransactionPayer(from),
+	)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	msg, err := tx.Message.MarshalBinary()
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.True(IsTransactionMessage(msg))
+
+	if _, err := tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		return &wallet.PrivateKey
+	}); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	signed, err := tx.MarshalBinary()
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.True(IsTransactionMessage(signed))
+
+	// trailing bytes mean it is not a single transaction message
+	assert.False(IsTransactionMessage(append(msg, 0x00)))
+
+	assert.False(IsTransactionMessage([]byte("hello")))
+	assert.False(IsTransactionMessage([]byte(strings.Repeat("sign in to example.com ", 50))))
+
+	envelope, err := New([32]byte{}, []solana.PublicKey{from}, []byte("hello"))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	bs, err := envelope.MarshalBinary()
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.False(IsTransactionMessage(bs))
+}
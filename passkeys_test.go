@@ -0,0 +1,91 @@
+package wallet
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/flarexio/identity/passkeys"
+)
+
+// fakePasskeys completes ceremonies without an authenticator. The assertion
+// echoes the challenge it was issued, and the token names the user and the
+// transaction the ceremony was started with.
+type fakePasskeys struct {
+	passkeys.Service
+	key       []byte
+	user      string // overrides the token subject when set
+	pending   map[string]*passkeys.InitializeTransactionRequest
+	finalized int
+}
+
+func newFakePasskeys() *fakePasskeys {
+	return &fakePasskeys{
+		key:     []byte("secret"),
+		pending: make(map[string]*passkeys.InitializeTransactionRequest),
+	}
+}
+
+func (p *fakePasskeys) InitializeTransaction(req *passkeys.InitializeTransactionRequest) (*protocol.CredentialAssertion, string, error) {
+	challenge := protocol.URLEncodedBase64(uuid.New().String())
+	p.pending[challenge.String()] = req
+
+	opts := &protocol.CredentialAssertion{
+		Response: protocol.PublicKeyCredentialRequestOptions{
+			Challenge: challenge,
+		},
+	}
+
+	return opts, "", nil
+}
+
+func (p *fakePasskeys) FinalizeTransaction(req *protocol.ParsedCredentialAssertionData) (string, error) {
+	challenge := req.Response.CollectedClientData.Challenge
+
+	r, ok := p.pending[challenge]
+	if !ok {
+		return "", errors.New("unknown challenge")
+	}
+
+	delete(p.pending, challenge)
+	p.finalized++
+
+	user := r.UserID
+	if p.user != "" {
+		user = p.user
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   user,
+		"trans": r.TransactionID,
+	})
+
+	return token.SignedString(p.key)
+}
+
+func (p *fakePasskeys) VerifyToken(token string) (*jwt.Token, error) {
+	return jwt.Parse(token, func(*jwt.Token) (any, error) {
+		return p.key, nil
+	}, jwt.WithValidMethods([]string{"HS256"}))
+}
+
+// assert answers the ceremony started with opts.
+func (p *fakePasskeys) assert(opts *protocol.CredentialAssertion) *protocol.ParsedCredentialAssertionData {
+	var req protocol.ParsedCredentialAssertionData
+	req.Response.CollectedClientData.Challenge = opts.Response.Challenge.String()
+	return &req
+}
+
+func newTestServiceWithPasskeys(t *testing.T) (*service, *fakePasskeys) {
+	t.Helper()
+
+	p := newFakePasskeys()
+
+	svc := newTestService(t)
+	svc.passkeys = p
+
+	return svc, p
+}
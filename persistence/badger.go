@@ -3,9 +3,11 @@ package persistence
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/dgraph-io/badger/v4"
+	"github.com/gagliardetto/solana-go"
 
 	"github.com/flarexio/wallet/account"
 	"github.com/flarexio/wallet/conf"
@@ -38,6 +40,19 @@ func (repo *badgerAccountRepository) Save(a *account.Account) error {
 	}
 
 	return repo.db.Update(func(txn *badger.Txn) error {
+		if _, err := txn.Get([]byte("del:" + a.Subject)); err == nil {
+			return account.ErrAccountDeleted
+		} else if !errors.Is(err, badger.ErrKeyNotFound) {
+			return err
+		}
+
+		for _, w := range a.Wallets {
+			walletKey := []byte("wal:" + w.PublicKey.String())
+			if err := txn.Set(walletKey, []byte(a.Subject)); err != nil {
+				return err
+			}
+		}
+
 		return txn.Set(key, bs)
 	})
 }
@@ -51,7 +66,7 @@ func (repo *badgerAccountRepository) Find(subject string) (*account.Account, err
 		item, err := txn.Get(key)
 		if err != nil {
 			if errors.Is(err, badger.ErrKeyNotFound) {
-				return account.ErrAccountNotFound
+				return notFound(txn, subject)
 			}
 
 			return err
@@ -67,26 +82,263 @@ func (repo *badgerAccountRepository) Find(subject string) (*account.Account, err
 	return a, nil
 }
 
+func (repo *badgerAccountRepository) FindByWallet(wallet solana.PublicKey) (*account.Account, error) {
+	var subject string
+
+	key := []byte("wal:" + wallet.String())
+
+	if err := repo.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				return account.ErrAccountNotFound
+			}
+
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			subject = string(val)
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	return repo.Find(subject)
+}
+
+func (repo *badgerAccountRepository) ForEach(fn func(a *account.Account) error) error {
+	prefix := []byte("sub:")
+
+	return repo.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			var a *account.Account
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &a)
+			}); err != nil {
+				return err
+			}
+
+			if err := fn(a); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (repo *badgerAccountRepository) List(filter account.ListFilter) ([]*account.Account, string, error) {
+	prefix := []byte("sub:")
+
+	start := prefix
+	if filter.Cursor != "" {
+		// seek past the cursor itself
+		start = []byte("sub:" + filter.Cursor + "\x00")
+	}
+
+	accounts := make([]*account.Account, 0)
+	var next string
+
+	if err := repo.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(start); it.ValidForPrefix(prefix); it.Next() {
+			var a *account.Account
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &a)
+			}); err != nil {
+				return err
+			}
+
+			if !filter.Match(a) {
+				continue
+			}
+
+			if filter.Limit > 0 && len(accounts) == filter.Limit {
+				next = accounts[len(accounts)-1].Subject
+				return nil
+			}
+
+			accounts = append(accounts, a)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, "", err
+	}
+
+	return accounts, next, nil
+}
+
+func (repo *badgerAccountRepository) Delete(subject string) error {
+	key := []byte("sub:" + subject)
+
+	return repo.db.Update(func(txn *badger.Txn) error {
+		// the tombstone is written even if the account is absent here
+		item, err := txn.Get(key)
+		switch {
+		case err == nil:
+			var a *account.Account
+			if err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &a)
+			}); err != nil {
+				return err
+			}
+
+			for _, w := range a.Wallets {
+				walletKey := []byte("wal:" + w.PublicKey.String())
+				if err := txn.Delete(walletKey); err != nil {
+					return err
+				}
+			}
+
+			if err := txn.Delete(key); err != nil {
+				return err
+			}
+
+		case !errors.Is(err, badger.ErrKeyNotFound):
+			return err
+		}
+
+		deletedAt, err := time.Now().MarshalText()
+		if err != nil {
+			return err
+		}
+
+		return txn.Set([]byte("del:"+subject), deletedAt)
+	})
+}
+
+// notFound tells a missing account apart from a deleted one.
+func notFound(txn *badger.Txn, subject string) error {
+	_, err := txn.Get([]byte("del:" + subject))
+	if err == nil {
+		return account.ErrAccountDeleted
+	}
+
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return account.ErrAccountNotFound
+	}
+
+	return err
+}
+
+func (repo *badgerAccountRepository) RecordAudit(e *account.AuditEvent) error {
+	key := fmt.Appendf(nil, "audit:%s:%020d", e.Subject, e.Time.UnixNano())
+
+	bs, err := json.Marshal(&e)
+	if err != nil {
+		return err
+	}
+
+	return repo.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(key, bs)
+	})
+}
+
+func (repo *badgerAccountRepository) AuditEvents(subject string) ([]*account.AuditEvent, error) {
+	prefix := []byte("audit:" + subject + ":")
+
+	events := make([]*account.AuditEvent, 0)
+	if err := repo.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			var e *account.AuditEvent
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &e)
+			}); err != nil {
+				return err
+			}
+
+			events = append(events, e)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// consumedTTL is how long consumed transaction IDs are remembered to detect
+// replays, and how long expired ones are kept to tell them from unknown IDs.
+const consumedTTL = 24 * time.Hour
+
 func (repo *badgerAccountRepository) CacheTransaction(t *account.Transaction, ttl time.Duration) error {
 	key := []byte("tx:" + t.TransactionID.String())
 
+	t.ExpiresAt = time.Now().Add(ttl)
+
 	bs, err := json.Marshal(&t)
 	if err != nil {
 		return err
 	}
 
 	return repo.db.Update(func(txn *badger.Txn) error {
-		e := badger.NewEntry(key, bs).WithTTL(ttl)
+		e := badger.NewEntry(key, bs).WithTTL(ttl + consumedTTL)
 		return txn.SetEntry(e)
 	})
 }
 
+func (repo *badgerAccountRepository) FindTransactionByID(id account.TransactionID) (*account.Transaction, error) {
+	var t *account.Transaction
+
+	key := []byte("tx:" + id.String())
+	consumedKey := []byte("txc:" + id.String())
+
+	err := repo.db.View(func(txn *badger.Txn) error {
+		if _, err := txn.Get(consumedKey); err == nil {
+			return account.ErrTransactionReplayed
+		} else if !errors.Is(err, badger.ErrKeyNotFound) {
+			return err
+		}
+
+		item, err := txn.Get(key)
+		if err != nil {
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				return account.ErrTransactionNotFound
+			}
+
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &t)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().After(t.ExpiresAt) {
+		return nil, account.ErrTransactionExpired
+	}
+
+	return t, nil
+}
+
 func (repo *badgerAccountRepository) RemoveTransactionByID(id account.TransactionID) (*account.Transaction, error) {
 	var t *account.Transaction
 
 	key := []byte("tx:" + id.String())
+	consumedKey := []byte("txc:" + id.String())
+
+	err := repo.db.Update(func(txn *badger.Txn) error {
+		if _, err := txn.Get(consumedKey); err == nil {
+			return account.ErrTransactionReplayed
+		} else if !errors.Is(err, badger.ErrKeyNotFound) {
+			return err
+		}
 
-	if err := repo.db.Update(func(txn *badger.Txn) error {
 		item, err := txn.Get(key)
 		if err != nil {
 			if errors.Is(err, badger.ErrKeyNotFound) {
@@ -102,11 +354,21 @@ func (repo *badgerAccountRepository) RemoveTransactionByID(id account.Transactio
 			return err
 		}
 
-		return txn.Delete(key)
-	}); err != nil {
+		if err := txn.Delete(key); err != nil {
+			return err
+		}
+
+		e := badger.NewEntry(consumedKey, nil).WithTTL(consumedTTL)
+		return txn.SetEntry(e)
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	if time.Now().After(t.ExpiresAt) {
+		return nil, account.ErrTransactionExpired
+	}
+
 	return t, nil
 }
 
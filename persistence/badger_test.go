@@ -0,0 +1,222 @@
+package persistence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flarexio/core/model"
+	"github.com/flarexio/wallet/account"
+	"github.com/flarexio/wallet/conf"
+)
+
+func TestBadgerList(t *testing.T) {
+	assert := assert.New(t)
+
+	repo, err := NewBadgerAccountRepository(&conf.BadgerPersistenceConfig{InMem: true})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer repo.Close()
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, subject := range []string{"a", "b", "c", "d", "e"} {
+		a := &account.Account{
+			Subject:    subject,
+			KeyVersion: 1 + i%2,
+			Model: model.Model{
+				CreatedAt: base.Add(time.Duration(i) * 24 * time.Hour),
+			},
+		}
+
+		if err := repo.Save(a); err != nil {
+			assert.Fail(err.Error())
+			return
+		}
+	}
+
+	accounts, next, err := repo.List(account.ListFilter{Limit: 2})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(accounts, 2)
+	assert.Equal("a", accounts[0].Subject)
+	assert.Equal("b", next)
+
+	accounts, next, err = repo.List(account.ListFilter{Cursor: next, Limit: 2})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal("c", accounts[0].Subject)
+	assert.Equal("d", next)
+
+	accounts, next, err = repo.List(account.ListFilter{Cursor: next, Limit: 2})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(accounts, 1)
+	assert.Empty(next)
+
+	accounts, _, err = repo.List(account.ListFilter{
+		CreatedAfter:  base.Add(24 * time.Hour),
+		CreatedBefore: base.Add(4 * 24 * time.Hour),
+		KeyVersion:    2,
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(accounts, 2)
+	assert.Equal("b", accounts[0].Subject)
+	assert.Equal("d", accounts[1].Subject)
+}
+
+func TestBadgerDelete(t *testing.T) {
+	assert := assert.New(t)
+
+	repo, err := NewBadgerAccountRepository(&conf.BadgerPersistenceConfig{InMem: true})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer repo.Close()
+
+	wallet := solana.NewWallet()
+
+	a := &account.Account{
+		Subject: "user",
+		Wallets: []*account.Wallet{
+			{PublicKey: wallet.PublicKey()},
+		},
+	}
+
+	if err := repo.Save(a); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if err := repo.Delete("user"); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	_, err = repo.Find("user")
+	assert.ErrorIs(err, account.ErrAccountDeleted)
+
+	_, err = repo.FindByWallet(wallet.PublicKey())
+	assert.ErrorIs(err, account.ErrAccountNotFound)
+
+	err = repo.Save(a)
+	assert.ErrorIs(err, account.ErrAccountDeleted)
+
+	_, err = repo.Find("other")
+	assert.ErrorIs(err, account.ErrAccountNotFound)
+
+	e := account.NewAuditEvent("user", account.AuditAccountDeleted)
+	if err := repo.RecordAudit(e); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	events, err := repo.AuditEvents("user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(events, 1)
+	assert.Equal(account.AuditAccountDeleted, events[0].Action)
+}
+
+func TestBadgerTransactions(t *testing.T) {
+	assert := assert.New(t)
+
+	repo, err := NewBadgerAccountRepository(&conf.BadgerPersistenceConfig{InMem: true})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer repo.Close()
+
+	id := "0b8f0b36-3b5e-4c2f-9a0e-6f1f0f3c2b1a"
+
+	tx, err := account.NewSignMessageTransaction(id, "user", 0, []byte("hello"))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if err := repo.CacheTransaction(tx, time.Minute); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	// finding does not consume
+	for range 2 {
+		found, err := repo.FindTransactionByID(tx.TransactionID)
+		if err != nil {
+			assert.Fail(err.Error())
+			return
+		}
+
+		assert.Equal("user", found.Subject)
+	}
+
+	cached, err := repo.RemoveTransactionByID(tx.TransactionID)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal("user", cached.Subject)
+
+	_, err = repo.RemoveTransactionByID(tx.TransactionID)
+	assert.ErrorIs(err, account.ErrTransactionReplayed)
+
+	_, err = repo.FindTransactionByID(tx.TransactionID)
+	assert.ErrorIs(err, account.ErrTransactionReplayed)
+
+	id = "5d3c1b7e-2f4a-4e8b-9c6d-7a8b9c0d1e2f"
+
+	tx, err = account.NewSignMessageTransaction(id, "user", 0, []byte("hello"))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if err := repo.CacheTransaction(tx, time.Millisecond); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	_, err = repo.FindTransactionByID(tx.TransactionID)
+	assert.ErrorIs(err, account.ErrTransactionExpired)
+
+	_, err = repo.RemoveTransactionByID(tx.TransactionID)
+	assert.ErrorIs(err, account.ErrTransactionExpired)
+
+	tid, err := account.ParseTransactionID("9e8d7c6b-5a4f-4e3d-8c2b-1a0f9e8d7c6b")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	_, err = repo.FindTransactionByID(tid)
+	assert.ErrorIs(err, account.ErrTransactionNotFound)
+
+	_, err = repo.RemoveTransactionByID(tid)
+	assert.ErrorIs(err, account.ErrTransactionNotFound)
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"time"
 
+	"github.com/gagliardetto/solana-go"
+
 	"github.com/flarexio/wallet/account"
 	"github.com/flarexio/wallet/conf"
 )
@@ -71,11 +73,16 @@ func (repo *compositeAccountRepository) Save(a *account.Account) error {
 }
 
 func (repo *compositeAccountRepository) Find(subject string) (*account.Account, error) {
-	if a, err := repo.cache.Find(subject); err == nil {
+	a, err := repo.cache.Find(subject)
+	if err == nil {
 		return a, nil
 	}
 
-	a, err := repo.main.Find(subject)
+	if errors.Is(err, account.ErrAccountDeleted) {
+		return nil, err
+	}
+
+	a, err = repo.main.Find(subject)
 	if err != nil {
 		return nil, err
 	}
@@ -85,10 +92,88 @@ func (repo *compositeAccountRepository) Find(subject string) (*account.Account,
 	return a, nil
 }
 
+func (repo *compositeAccountRepository) FindByWallet(wallet solana.PublicKey) (*account.Account, error) {
+	if a, err := repo.cache.FindByWallet(wallet); err == nil {
+		return a, nil
+	}
+
+	a, err := repo.main.FindByWallet(wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	go repo.cache.Save(a)
+
+	return a, nil
+}
+
+// ForEach walks the main layer, or the cache layer when the main layer
+// cannot enumerate its accounts.
+func (repo *compositeAccountRepository) ForEach(fn func(a *account.Account) error) error {
+	err := repo.main.ForEach(fn)
+	if errors.Is(err, ErrNotImplemented) {
+		return repo.cache.ForEach(fn)
+	}
+
+	return err
+}
+
+// List pages through the main layer, or the cache layer when the main layer
+// cannot list its accounts.
+func (repo *compositeAccountRepository) List(filter account.ListFilter) ([]*account.Account, string, error) {
+	accounts, next, err := repo.main.List(filter)
+	if errors.Is(err, ErrNotImplemented) {
+		return repo.cache.List(filter)
+	}
+
+	return accounts, next, err
+}
+
+// Delete erases the account from both layers synchronously. The cache is
+// always tombstoned, even when it never held the account, so that a pending
+// backfill cannot resurrect it. A main layer that cannot delete, or that no
+// longer holds the account, is skipped; a retry after a failed cache write
+// therefore completes the deletion.
+func (repo *compositeAccountRepository) Delete(subject string) error {
+	if err := repo.main.Delete(subject); err != nil &&
+		!errors.Is(err, ErrNotImplemented) &&
+		!errors.Is(err, account.ErrAccountNotFound) &&
+		!errors.Is(err, account.ErrAccountDeleted) {
+		return err
+	}
+
+	return repo.cache.Delete(subject)
+}
+
+// RecordAudit writes to the main layer, or to the cache layer when the main
+// layer cannot store audit events.
+func (repo *compositeAccountRepository) RecordAudit(e *account.AuditEvent) error {
+	err := repo.main.RecordAudit(e)
+	if errors.Is(err, ErrNotImplemented) {
+		return repo.cache.RecordAudit(e)
+	}
+
+	return err
+}
+
+// AuditEvents reads from the layer RecordAudit writes to.
+func (repo *compositeAccountRepository) AuditEvents(subject string) ([]*account.AuditEvent, error) {
+	events, err := repo.main.AuditEvents(subject)
+	if errors.Is(err, ErrNotImplemented) {
+		return repo.cache.AuditEvents(subject)
+	}
+
+	return events, err
+}
+
 func (repo *compositeAccountRepository) CacheTransaction(t *account.Transaction, ttl time.Duration) error {
 	return repo.cache.CacheTransaction(t, ttl)
 }
 
+func (repo *compositeAccountRepository) FindTransactionByID(id account.TransactionID) (*account.Transaction, error) {
+	return repo.cache.FindTransactionByID(id)
+}
+
 func (repo *compositeAccountRepository) RemoveTransactionByID(id account.TransactionID) (*account.Transaction, error) {
 	return repo.cache.RemoveTransactionByID(id)
 }
@@ -0,0 +1,191 @@
+package persistence
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flarexio/wallet/account"
+	"github.com/flarexio/wallet/conf"
+)
+
+// newTestComposite mirrors the shipped configuration: a solana main layer
+// that implements nothing yet in front of a Badger cache holding the records.
+func newTestComposite(t *testing.T) (*compositeAccountRepository, account.Repository) {
+	t.Helper()
+
+	cache, err := NewBadgerAccountRepository(&conf.BadgerPersistenceConfig{InMem: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &compositeAccountRepository{
+		main:  &solanaAccountRepository{},
+		cache: cache,
+	}
+	t.Cleanup(func() { repo.Close() })
+
+	for _, subject := range []string{"a", "b", "c"} {
+		if err := cache.Save(&account.Account{Subject: subject}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return repo, cache
+}
+
+func TestCompositeForEach(t *testing.T) {
+	assert := assert.New(t)
+
+	repo, _ := newTestComposite(t)
+
+	var subjects []string
+	if err := repo.ForEach(func(a *account.Account) error {
+		subjects = append(subjects, a.Subject)
+		return nil
+	}); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal([]string{"a", "b", "c"}, subjects)
+}
+
+func TestCompositeList(t *testing.T) {
+	assert := assert.New(t)
+
+	repo, _ := newTestComposite(t)
+
+	accounts, next, err := repo.List(account.ListFilter{Limit: 2})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if !assert.Len(accounts, 2) {
+		return
+	}
+
+	assert.Equal("a", accounts[0].Subject)
+	assert.Equal("b", next)
+
+	accounts, next, err = repo.List(account.ListFilter{Cursor: next, Limit: 2})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(accounts, 1)
+	assert.Empty(next)
+}
+
+// deleteOnceRepository is a main layer that forgets an account once it has
+// been deleted.
+type deleteOnceRepository struct {
+	solanaAccountRepository
+	deleted map[string]bool
+}
+
+func (repo *deleteOnceRepository) Delete(subject string) error {
+	if repo.deleted[subject] {
+		return account.ErrAccountNotFound
+	}
+
+	repo.deleted[subject] = true
+	return nil
+}
+
+// failingDeleteRepository fails the next Delete with err.
+type failingDeleteRepository struct {
+	account.Repository
+	err error
+}
+
+func (repo *failingDeleteRepository) Delete(subject string) error {
+	if err := repo.err; err != nil {
+		repo.err = nil
+		return err
+	}
+
+	return repo.Repository.Delete(subject)
+}
+
+func TestCompositeDelete(t *testing.T) {
+	assert := assert.New(t)
+
+	repo, cache := newTestComposite(t)
+
+	if err := repo.Delete("a"); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	_, err := repo.Find("a")
+	assert.ErrorIs(err, account.ErrAccountDeleted)
+
+	e := account.NewAuditEvent("a", account.AuditAccountDeleted)
+	if err := repo.RecordAudit(e); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	events, err := cache.AuditEvents("a")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(events, 1)
+}
+
+func TestCompositeDeleteRetry(t *testing.T) {
+	assert := assert.New(t)
+
+	_, cache := newTestComposite(t)
+
+	errDisk := errors.New("disk full")
+
+	repo := &compositeAccountRepository{
+		main:  &deleteOnceRepository{deleted: make(map[string]bool)},
+		cache: &failingDeleteRepository{Repository: cache, err: errDisk},
+	}
+
+	// main is erased but the cache is not
+	err := repo.Delete("a")
+	assert.ErrorIs(err, errDisk)
+
+	if _, err := repo.Find("a"); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if err := repo.Delete("a"); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	_, err = repo.Find("a")
+	assert.ErrorIs(err, account.ErrAccountDeleted)
+}
+
+func TestCompositeAuditEvents(t *testing.T) {
+	assert := assert.New(t)
+
+	repo, _ := newTestComposite(t)
+
+	for _, action := range []account.AuditAction{account.AuditKeyExported, account.AuditTransactionSponsored} {
+		if err := repo.RecordAudit(account.NewAuditEvent("a", action)); err != nil {
+			assert.Fail(err.Error())
+			return
+		}
+	}
+
+	events, err := repo.AuditEvents("a")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(events, 2)
+}
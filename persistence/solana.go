@@ -11,6 +11,10 @@ import (
 	"github.com/flarexio/wallet/conf"
 )
 
+// ErrNotImplemented is returned by layers that cannot serve an operation yet;
+// the composite repository falls back to its cache layer on it.
+var ErrNotImplemented = errors.New("not implemented")
+
 func NewSolanaAccountRepository(cfg *conf.SolanaPersistenceConfig) (account.Repository, error) {
 	client := rpc.New(cfg.RPC)
 
@@ -36,19 +40,47 @@ type solanaAccountRepository struct {
 }
 
 func (repo *solanaAccountRepository) Save(a *account.Account) error {
-	return errors.New("not implemented")
+	return ErrNotImplemented
 }
 
 func (repo *solanaAccountRepository) Find(subject string) (*account.Account, error) {
-	return nil, errors.New("not implemented")
+	return nil, ErrNotImplemented
+}
+
+func (repo *solanaAccountRepository) FindByWallet(wallet solana.PublicKey) (*account.Account, error) {
+	return nil, ErrNotImplemented
+}
+
+func (repo *solanaAccountRepository) ForEach(fn func(a *account.Account) error) error {
+	return ErrNotImplemented
+}
+
+func (repo *solanaAccountRepository) List(filter account.ListFilter) ([]*account.Account, string, error) {
+	return nil, "", ErrNotImplemented
+}
+
+func (repo *solanaAccountRepository) Delete(subject string) error {
+	return ErrNotImplemented
+}
+
+func (repo *solanaAccountRepository) RecordAudit(e *account.AuditEvent) error {
+	return ErrNotImplemented
+}
+
+func (repo *solanaAccountRepository) AuditEvents(subject string) ([]*account.AuditEvent, error) {
+	return nil, ErrNotImplemented
 }
 
 func (repo *solanaAccountRepository) CacheTransaction(t *account.Transaction, ttl time.Duration) error {
-	return errors.New("not implemented")
+	return ErrNotImplemented
+}
+
+func (repo *solanaAccountRepository) FindTransactionByID(id account.TransactionID) (*account.Transaction, error) {
+	return nil, ErrNotImplemented
 }
 
 func (repo *solanaAccountRepository) RemoveTransactionByID(id account.TransactionID) (*account.Transaction, error) {
-	return nil, errors.New("not implemented")
+	return nil, ErrNotImplemented
 }
 
 func (repo *solanaAccountRepository) Close() error {
@@ -0,0 +1,288 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Portfolio is a snapshot of a wallet's holdings. Reserves are the lamports
+// an account must keep to stay rent exempt; a token account's reserve is
+// returned when it is closed.
+type Portfolio struct {
+	Wallet            solana.PublicKey `json:"wallet"`
+	Slot              uint64           `json:"slot"`
+	Lamports          uint64           `json:"lamports"`
+	RentExemptReserve uint64           `json:"rent_exempt_reserve"`
+	Tokens            []*TokenHolding  `json:"tokens"`
+	FetchedAt         time.Time        `json:"fetched_at"`
+}
+
+type TokenHolding struct {
+	Account           solana.PublicKey `json:"account"`
+	Mint              solana.PublicKey `json:"mint"`
+	Program           solana.PublicKey `json:"program"`
+	Amount            uint64           `json:"amount"`
+	Decimals          uint8            `json:"decimals"`
+	UIAmount          string           `json:"ui_amount"`
+	Frozen            bool             `json:"frozen"`
+	Lamports          uint64           `json:"lamports"`
+	RentExemptReserve uint64           `json:"rent_exempt_reserve"`
+}
+
+// formatAmount renders base units as a decimal string without losing
+// precision to floating point.
+func formatAmount(amount uint64, decimals uint8) string {
+	s := strconv.FormatUint(amount, 10)
+	if decimals == 0 {
+		return s
+	}
+
+	d := int(decimals)
+	if len(s) <= d {
+		s = strings.Repeat("0", d-len(s)+1) + s
+	}
+
+	whole, frac := s[:len(s)-d], strings.TrimRight(s[len(s)-d:], "0")
+	if frac == "" {
+		return whole
+	}
+
+	return whole + "." + frac
+}
+
+// Portfolio returns the holdings of the wallet at index. Snapshots are cached
+// per wallet for the configured TTL.
+func (svc *service) Portfolio(ctx context.Context, subject string, index int) (*Portfolio, error) {
+	if svc.rpc == nil {
+		return nil, ErrSolanaUnavailable
+	}
+
+	wallet, err := svc.walletKey(subject, index)
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.portfolios.get(ctx, wallet, func(ctx context.Context) (*Portfolio, error) {
+		return svc.portfolio(ctx, wallet)
+	})
+}
+
+func (svc *service) portfolio(ctx context.Context, wallet solana.PublicKey) (*Portfolio, error) {
+	commitment := rpc.CommitmentType(svc.solana.Commitment)
+
+	balance, err := svc.rpc.GetBalance(ctx, wallet, commitment)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Portfolio{
+		Wallet:    wallet,
+		Slot:      balance.Context.Slot,
+		Lamports:  balance.Value,
+		Tokens:    make([]*TokenHolding, 0),
+		FetchedAt: time.Now(),
+	}
+
+	rents := make(map[uint64]uint64)
+	rent := func(size uint64) (uint64, error) {
+		if lamports, ok := rents[size]; ok {
+			return lamports, nil
+		}
+
+		lamports, err := svc.rpc.GetMinimumBalanceForRentExemption(ctx, size, commitment)
+		if err != nil {
+			return 0, err
+		}
+
+		rents[size] = lamports
+		return lamports, nil
+	}
+
+	p.RentExemptReserve, err = rent(0)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, program := range []solana.PublicKey{solana.TokenProgramID, solana.Token2022ProgramID} {
+		result, err := svc.rpc.GetTokenAccountsByOwner(ctx, wallet,
+			&rpc.GetTokenAccountsConfig{ProgramId: &program},
+			&rpc.GetTokenAccountsOpts{
+				Encoding:   solana.EncodingBase64,
+				Commitment: commitment,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, acc := range result.Value {
+			token, ok := parseTokenAccount(&acc.Account)
+			if !ok {
+				continue
+			}
+
+			reserve, err := rent(uint64(len(acc.Account.Data.GetBinary())))
+			if err != nil {
+				return nil, err
+			}
+
+			p.Tokens = append(p.Tokens, &TokenHolding{
+				Account:           acc.Pubkey,
+				Mint:              token.mint,
+				Program:           program,
+				Amount:            token.amount,
+				Frozen:            token.frozen,
+				Lamports:          acc.Account.Lamports,
+				RentExemptReserve: reserve,
+			})
+		}
+	}
+
+	if err := svc.resolveDecimals(ctx, p.Tokens); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(p.Tokens, func(i, j int) bool {
+		if p.Tokens[i].Mint.Equals(p.Tokens[j].Mint) {
+			return p.Tokens[i].Account.String() < p.Tokens[j].Account.String()
+		}
+
+		return p.Tokens[i].Mint.String() < p.Tokens[j].Mint.String()
+	})
+
+	return p, nil
+}
+
+// resolveDecimals fetches every distinct mint in one call.
+func (svc *service) resolveDecimals(ctx context.Context, tokens []*TokenHolding) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	var mints []solana.PublicKey
+	seen := make(map[solana.PublicKey]bool)
+	for _, token := range tokens {
+		if !seen[token.Mint] {
+			seen[token.Mint] = true
+			mints = append(mints, token.Mint)
+		}
+	}
+
+	result, err := svc.rpc.GetMultipleAccountsWithOpts(ctx, mints, &rpc.GetMultipleAccountsOpts{
+		Encoding:   solana.EncodingBase64,
+		Commitment: rpc.CommitmentType(svc.solana.Commitment),
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(result.Value) != len(mints) {
+		return fmt.Errorf("expected %d mint accounts, got %d", len(mints), len(result.Value))
+	}
+
+	decimals := make(map[solana.PublicKey]uint8)
+	for i, acc := range result.Value {
+		info, err := parseMint(acc)
+		if err != nil {
+			return fmt.Errorf("mint %s: %w", mints[i], err)
+		}
+
+		decimals[mints[i]] = info.decimals
+	}
+
+	for _, token := range tokens {
+		token.Decimals = decimals[token.Mint]
+		token.UIAmount = formatAmount(token.Amount, token.Decimals)
+	}
+
+	return nil
+}
+
+// portfolioFetchTimeout bounds a shared fetch, which no longer follows the
+// request that started it.
+const portfolioFetchTimeout = 30 * time.Second
+
+// portfolioCache keeps snapshots for ttl and lets concurrent requests for
+// the same wallet share a single fetch. Errors are not cached.
+type portfolioCache struct {
+	ttl     time.Duration
+	entries map[solana.PublicKey]*portfolioEntry
+	sync.Mutex
+}
+
+type portfolioEntry struct {
+	done      chan struct{}
+	portfolio *Portfolio
+	err       error
+	expires   time.Time
+}
+
+func newPortfolioCache(ttl time.Duration) *portfolioCache {
+	return &portfolioCache{
+		ttl:     ttl,
+		entries: make(map[solana.PublicKey]*portfolioEntry),
+	}
+}
+
+// get returns the cached snapshot or joins the fetch in flight. The fetch
+// runs detached from ctx, so that a caller giving up does not fail the
+// others waiting on it.
+func (c *portfolioCache) get(ctx context.Context, wallet solana.PublicKey, fetch func(context.Context) (*Portfolio, error)) (*Portfolio, error) {
+	now := time.Now()
+
+	c.Lock()
+	e, ok := c.entries[wallet]
+	if !ok || e.fetched() && !now.Before(e.expires) {
+		for key, entry := range c.entries {
+			if entry.fetched() && !now.Before(entry.expires) {
+				delete(c.entries, key)
+			}
+		}
+
+		e = &portfolioEntry{done: make(chan struct{})}
+		c.entries[wallet] = e
+
+		go c.fetch(context.WithoutCancel(ctx), wallet, e, fetch)
+	}
+	c.Unlock()
+
+	select {
+	case <-e.done:
+		return e.portfolio, e.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *portfolioCache) fetch(ctx context.Context, wallet solana.PublicKey, e *portfolioEntry, fetch func(context.Context) (*Portfolio, error)) {
+	ctx, cancel := context.WithTimeout(ctx, portfolioFetchTimeout)
+	defer cancel()
+
+	e.portfolio, e.err = fetch(ctx)
+
+	c.Lock()
+	e.expires = time.Now().Add(c.ttl)
+	if e.err != nil && c.entries[wallet] == e {
+		delete(c.entries, wallet)
+	}
+	c.Unlock()
+
+	close(e.done)
+}
+
+func (e *portfolioEntry) fetched() bool {
+	select {
+	case <-e.done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,212 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatAmount(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("0", formatAmount(0, 6))
+	assert.Equal("1.5", formatAmount(1_500_000, 6))
+	assert.Equal("0.000001", formatAmount(1, 6))
+	assert.Equal("42", formatAmount(42_000_000_000, 9))
+	assert.Equal("18446744073.709551615", formatAmount(18446744073709551615, 9))
+	assert.Equal("7", formatAmount(7, 0))
+}
+
+func TestParseTokenAccountFrozen(t *testing.T) {
+	assert := assert.New(t)
+
+	data := make([]byte, tokenAccountSize)
+	data[108] = tokenAccountFrozen
+
+	token, ok := parseTokenAccount(&rpc.Account{
+		Owner: solana.Token2022ProgramID,
+		Data:  rpc.DataBytesOrJSONFromBytes(data),
+	})
+	if !assert.True(ok) {
+		return
+	}
+
+	assert.True(token.frozen)
+}
+
+func TestPortfolioCache(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	cache := newPortfolioCache(time.Minute)
+	wallet := solana.NewWallet().PublicKey()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	fetch := func(context.Context) (*Portfolio, error) {
+		calls.Add(1)
+		<-release
+		return &Portfolio{Wallet: wallet}, nil
+	}
+
+	// concurrent misses share one fetch
+	var wg sync.WaitGroup
+	results := make([]*Portfolio, 5)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = cache.get(ctx, wallet, fetch)
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(int32(1), calls.Load())
+	for _, p := range results {
+		assert.Same(results[0], p)
+	}
+
+	// fresh entries are served from the cache
+	p, err := cache.get(ctx, wallet, fetch)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Same(results[0], p)
+	assert.Equal(int32(1), calls.Load())
+
+	// expired entries are fetched again
+	cache.entries[wallet].expires = time.Now().Add(-time.Second)
+
+	p, err = cache.get(ctx, wallet, fetch)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.NotSame(results[0], p)
+	assert.Equal(int32(2), calls.Load())
+
+	// errors are not cached
+	other := solana.NewWallet().PublicKey()
+	errRPC := errors.New("rpc unavailable")
+
+	_, err = cache.get(ctx, other, func(context.Context) (*Portfolio, error) {
+		return nil, errRPC
+	})
+	assert.ErrorIs(err, errRPC)
+	assert.NotContains(cache.entries, other)
+}
+
+func TestPortfolioCacheCanceled(t *testing.T) {
+	assert := assert.New(t)
+
+	cache := newPortfolioCache(time.Minute)
+	wallet := solana.NewWallet().PublicKey()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	fetch := func(ctx context.Context) (*Portfolio, error) {
+		close(started)
+		<-release
+
+		// the fetch does not follow the caller that started it
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		return &Portfolio{Wallet: wallet}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := cache.get(ctx, wallet, fetch)
+		first <- err
+	}()
+
+	<-started
+
+	waiter := make(chan *Portfolio, 1)
+	go func() {
+		p, _ := cache.get(context.Background(), wallet, fetch)
+		waiter <- p
+	}()
+
+	// the first caller gives up while the fetch is in flight
+	cancel()
+	assert.ErrorIs(<-first, context.Canceled)
+
+	close(release)
+
+	p := <-waiter
+	if !assert.NotNil(p) {
+		return
+	}
+
+	assert.Equal(wallet, p.Wallet)
+}
+
+// TestPortfolio runs against a local solana-test-validator, e.g.
+// SOLANA_TEST_VALIDATOR_RPC=http://127.0.0.1:8899
+func TestPortfolio(t *testing.T) {
+	endpoint := os.Getenv("SOLANA_TEST_VALIDATOR_RPC")
+	if endpoint == "" {
+		t.Skip("SOLANA_TEST_VALIDATOR_RPC is not set")
+	}
+
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	svc.rpc = rpc.New(endpoint)
+	svc.solana.Commitment = "confirmed"
+	svc.portfolios = newPortfolioCache(time.Minute)
+
+	wallet, err := svc.Wallet(ctx, "user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if err := airdrop(ctx, svc.rpc, wallet); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	p, err := svc.Portfolio(ctx, "user", 0)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(wallet, p.Wallet)
+	assert.Equal(solana.LAMPORTS_PER_SOL, p.Lamports)
+	assert.Equal(uint64(890_880), p.RentExemptReserve)
+	assert.Empty(p.Tokens)
+
+	cached, err := svc.Portfolio(ctx, "user", 0)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Same(p, cached)
+}
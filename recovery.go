@@ -0,0 +1,228 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+
+	"github.com/gagliardetto/solana-go"
+
+	"github.com/flarexio/wallet/account"
+)
+
+type RecoveryStatus string
+
+const (
+	RecoveryStatusVerified RecoveryStatus = "verified"
+	RecoveryStatusRestored RecoveryStatus = "restored"
+	RecoveryStatusMissing  RecoveryStatus = "missing"
+	RecoveryStatusMismatch RecoveryStatus = "mismatch"
+	RecoveryStatusFailed   RecoveryStatus = "failed"
+
+	// RecoveryStatusUnrecoverable marks an imported wallet whose sealed key
+	// is lost; it cannot be re-derived from the master key.
+	RecoveryStatusUnrecoverable RecoveryStatus = "unrecoverable"
+)
+
+type RecoveryResult struct {
+	Subject    string           `json:"subject"`
+	Index      int              `json:"index"`
+	Wallet     solana.PublicKey `json:"wallet"`
+	KeyVersion int              `json:"key_version"`
+	Status     RecoveryStatus   `json:"status"`
+	Error      string           `json:"error,omitempty"`
+}
+
+func (svc *service) RecoverAccount(ctx context.Context, subject string, restore bool) ([]*RecoveryResult, error) {
+	svc.accountsLock.Lock()
+	defer svc.accountsLock.Unlock()
+
+	a, err := svc.accounts.Find(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.recover(ctx, a, restore), nil
+}
+
+// RecoverAccounts only collects subjects while scanning; each account is read
+// again under the lock, so that a change made since the scan is not undone
+// by the restore.
+func (svc *service) RecoverAccounts(ctx context.Context, restore bool) ([]*RecoveryResult, error) {
+	subjects := make([]string, 0)
+	if err := svc.accounts.ForEach(func(a *account.Account) error {
+		subjects = append(subjects, a.Subject)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	results := make([]*RecoveryResult, 0, len(subjects))
+	for _, subject := range subjects {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		recovered, err := svc.RecoverAccount(ctx, subject, restore)
+		if err != nil {
+			// deleted since the scan
+			if errors.Is(err, account.ErrAccountNotFound) || errors.Is(err, account.ErrAccountDeleted) {
+				continue
+			}
+
+			results = append(results, &RecoveryResult{
+				Subject: subject,
+				Status:  RecoveryStatusFailed,
+				Error:   err.Error(),
+			})
+
+			continue
+		}
+
+		results = append(results, recovered...)
+	}
+
+	return results, nil
+}
+
+// recover re-derives every wallet key from Subject, Salt, index and
+// KeyVersion and compares it with the stored wallet. Lost or unreadable key
+// material is restored from the derived key when restore is set; a wallet
+// mismatch is only reported, never overwritten.
+func (svc *service) recover(ctx context.Context, a *account.Account, restore bool) []*RecoveryResult {
+	results := make([]*RecoveryResult, 0, len(a.Wallets))
+
+	fail := func(result *RecoveryResult, err error) {
+		result.Status = RecoveryStatusFailed
+		result.Error = err.Error()
+	}
+
+	key, err := svc.keys.Key(ctx, a.KeyVersion)
+	if err != nil {
+		result := &RecoveryResult{
+			Subject:    a.Subject,
+			KeyVersion: a.KeyVersion,
+		}
+
+		fail(result, err)
+		return append(results, result)
+	}
+
+	restored := a.Clone()
+	var dirty bool
+
+	for _, w := range restored.Wallets {
+		result := &RecoveryResult{
+			Subject:    a.Subject,
+			Index:      w.Index,
+			Wallet:     w.PublicKey,
+			KeyVersion: a.KeyVersion,
+		}
+
+		results = append(results, result)
+
+		if w.Imported {
+			svc.verifyImported(ctx, a, w, result)
+			continue
+		}
+
+		privkey, err := account.DeriveKey(ctx, a.Subject, a.Salt, w.Index, key)
+		if err != nil {
+			fail(result, err)
+			continue
+		}
+
+		derived := solana.PublicKeyFromBytes(privkey.Public().(ed25519.PublicKey))
+
+		if w.PublicKey.IsZero() {
+			result.Wallet = derived
+		} else if !w.PublicKey.Equals(derived) {
+			result.Status = RecoveryStatusMismatch
+			continue
+		}
+
+		var missing bool
+		switch {
+		case w.EncryptedKey != nil:
+			sealKey, err := svc.keys.Key(ctx, w.EncryptedKey.KeyVersion)
+			if err != nil {
+				fail(result, err)
+				continue
+			}
+
+			stored, err := a.Unseal(ctx, sealKey, w.Index)
+			missing = err != nil || !stored.Equal(privkey)
+
+		case len(w.PrivateKey) == ed25519.PrivateKeySize:
+			missing = !w.PrivateKey.Equal(privkey)
+
+		default:
+			missing = true
+		}
+
+		if !missing {
+			result.Status = RecoveryStatusVerified
+			continue
+		}
+
+		if !restore {
+			result.Status = RecoveryStatusMissing
+			continue
+		}
+
+		w.PublicKey = derived
+		w.PrivateKey = privkey
+		w.EncryptedKey = nil
+
+		result.Status = RecoveryStatusRestored
+		dirty = true
+	}
+
+	if !dirty {
+		return results
+	}
+
+	err = restored.Seal(ctx, key)
+	if err == nil {
+		err = svc.accounts.Save(restored)
+	}
+
+	if err != nil {
+		for _, result := range results {
+			if result.Status == RecoveryStatusRestored {
+				fail(result, err)
+			}
+		}
+	}
+
+	return results
+}
+
+// verifyImported checks that the sealed key of an imported wallet still opens
+// to its address. There is nothing to restore it from.
+func (svc *service) verifyImported(ctx context.Context, a *account.Account, w *account.Wallet, result *RecoveryResult) {
+	if w.EncryptedKey == nil {
+		if len(w.PrivateKey) == ed25519.PrivateKeySize {
+			result.Status = RecoveryStatusVerified
+		} else {
+			result.Status = RecoveryStatusUnrecoverable
+		}
+
+		return
+	}
+
+	key, err := svc.keys.Key(ctx, w.EncryptedKey.KeyVersion)
+	if err != nil {
+		result.Status = RecoveryStatusFailed
+		result.Error = err.Error()
+		return
+	}
+
+	if _, err := a.Unseal(ctx, key, w.Index); err != nil {
+		result.Status = RecoveryStatusUnrecoverable
+		result.Error = err.Error()
+		return
+	}
+
+	result.Status = RecoveryStatusVerified
+}
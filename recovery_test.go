@@ -0,0 +1,305 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flarexio/wallet/account"
+	"github.com/flarexio/wallet/conf"
+	"github.com/flarexio/wallet/persistence"
+)
+
+func newTestService(t *testing.T) *service {
+	t.Helper()
+
+	repo, err := persistence.NewBadgerAccountRepository(&conf.BadgerPersistenceConfig{InMem: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+
+	cfg := conf.Config{
+		Keys: conf.KeyConfig{
+			Driver: conf.KeyDriverLocal,
+			Local: &conf.LocalKeyConfig{
+				Name:       "keystore.json",
+				Path:       t.TempDir(),
+				Passphrase: "passphrase",
+			},
+		},
+	}
+
+	svc, err := NewService(repo, nil, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { svc.Close() })
+
+	return svc.(*service)
+}
+
+func TestRecoverAccount(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	wallet, err := svc.Wallet(ctx, "user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	savings, err := svc.CreateWallet(ctx, "user", "savings")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	results, err := svc.RecoverAccount(ctx, "user", false)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(results, 2)
+	assert.Equal(RecoveryStatusVerified, results[0].Status)
+	assert.Equal(wallet, results[0].Wallet)
+	assert.Equal(RecoveryStatusVerified, results[1].Status)
+	assert.Equal(savings.PublicKey, results[1].Wallet)
+
+	// simulate a storage incident that lost the key material
+	a, err := svc.accounts.Find("user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	a.Wallets[1].EncryptedKey = nil
+	if err := svc.accounts.Save(a); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	results, err = svc.RecoverAccounts(ctx, false)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(results, 2)
+	assert.Equal(RecoveryStatusVerified, results[0].Status)
+	assert.Equal(RecoveryStatusMissing, results[1].Status)
+
+	results, err = svc.RecoverAccount(ctx, "user", true)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(RecoveryStatusRestored, results[1].Status)
+
+	_, err = svc.SignMessage(ctx, "user", 1, []byte("test"))
+	assert.NoError(err)
+
+	// a different salt derives different wallets
+	a, _ = svc.accounts.Find("user")
+	a.Salt = "tampered"
+	svc.accounts.Save(a)
+
+	results, err = svc.RecoverAccount(ctx, "user", true)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(RecoveryStatusMismatch, results[0].Status)
+	assert.Equal(wallet, results[0].Wallet)
+}
+
+// TestRecoverAccountsComposite recovers through the shipped persistence
+// layout, where the solana main layer cannot enumerate accounts and the
+// records live in the Badger cache.
+func TestRecoverAccountsComposite(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	path := t.TempDir()
+
+	cfg := conf.Config{
+		Keys: conf.KeyConfig{
+			Driver: conf.KeyDriverLocal,
+			Local: &conf.LocalKeyConfig{
+				Name:       "keystore.json",
+				Path:       path,
+				Passphrase: "passphrase",
+			},
+		},
+	}
+
+	cacheCfg := &conf.BadgerPersistenceConfig{Name: "wallets", Path: path}
+
+	// populate the cache the way earlier requests would have
+	cache, err := persistence.NewBadgerAccountRepository(cacheCfg)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	seeder, err := NewService(cache, nil, cfg)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	for _, subject := range []string{"alice", "bob"} {
+		if _, err := seeder.Wallet(ctx, subject); err != nil {
+			assert.Fail(err.Error())
+			return
+		}
+	}
+
+	seeder.Close()
+	cache.Close()
+
+	key := solana.NewWallet().PrivateKey
+
+	keygen := make([]int, len(key))
+	for i, b := range key {
+		keygen[i] = int(b)
+	}
+
+	bs, err := json.Marshal(keygen)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if err := os.WriteFile(filepath.Join(path, "id.json"), bs, 0600); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	repo, err := persistence.NewCompositeAccountRepository(&conf.CompositePersistenceConfig{
+		Main: conf.PersistenceConfig{
+			Driver: conf.PersistenceDriverSolana,
+			Solana: &conf.SolanaPersistenceConfig{
+				RPC:     "http://127.0.0.1:8899",
+				Program: solana.SystemProgramID.String(),
+				Path:    path,
+				Account: "id.json",
+			},
+		},
+		Cache: conf.PersistenceConfig{
+			Driver: conf.PersistenceDriverBadger,
+			Badger: cacheCfg,
+		},
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer repo.Close()
+
+	svc, err := NewService(repo, nil, cfg)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer svc.Close()
+
+	results, err := svc.RecoverAccounts(ctx, false)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if !assert.Len(results, 2) {
+		return
+	}
+
+	assert.Equal("alice", results[0].Subject)
+	assert.Equal(RecoveryStatusVerified, results[0].Status)
+	assert.Equal("bob", results[1].Subject)
+	assert.Equal(RecoveryStatusVerified, results[1].Status)
+}
+
+// scanHookRepository runs hook after a scan, as if another request had
+// changed the accounts while RecoverAccounts was working through them.
+type scanHookRepository struct {
+	account.Repository
+	hook func()
+}
+
+func (repo *scanHookRepository) ForEach(fn func(a *account.Account) error) error {
+	if err := repo.Repository.ForEach(fn); err != nil {
+		return err
+	}
+
+	repo.hook()
+	return nil
+}
+
+func TestRecoverAccountsConcurrentFreeze(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	if _, err := svc.Wallet(ctx, "user"); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	// lose the key material so that recovery restores and saves
+	a, err := svc.accounts.Find("user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	a.Wallets[0].EncryptedKey = nil
+	if err := svc.accounts.Save(a); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	svc.accounts = &scanHookRepository{
+		Repository: svc.accounts,
+		hook: func() {
+			if _, err := svc.FreezeAccount(ctx, "user", "admin", "suspicious"); err != nil {
+				t.Fatal(err)
+			}
+		},
+	}
+
+	results, err := svc.RecoverAccounts(ctx, true)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if !assert.Len(results, 1) {
+		return
+	}
+
+	assert.Equal(RecoveryStatusRestored, results[0].Status)
+
+	// the freeze made during the scan survives the restore
+	a, err = svc.accounts.Find("user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.True(a.IsFrozen())
+}
@@ -5,10 +5,13 @@ import (
 	"crypto/ed25519"
 	"crypto/sha256"
 	"errors"
+	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/go-webauthn/webauthn/protocol"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/mr-tron/base58"
@@ -16,19 +19,57 @@ import (
 	"github.com/flarexio/identity/passkeys"
 	"github.com/flarexio/wallet/account"
 	"github.com/flarexio/wallet/conf"
+	"github.com/flarexio/wallet/inspect"
 	"github.com/flarexio/wallet/keys"
+	"github.com/flarexio/wallet/offchain"
+	"github.com/flarexio/wallet/siws"
 )
 
 type Service interface {
-	Wallet(subject string) (solana.PublicKey, error)
-
-	SignMessage(subject string, message []byte) (solana.Signature, error)
-	InitializeSignMessage(req *InitializeSignMessageRequest) (*protocol.CredentialAssertion, string, error)
-	FinalizeSignMessage(req *protocol.ParsedCredentialAssertionData) (solana.Signature, error)
-
-	SignTransaction(subject string, transaction *solana.Transaction) ([]solana.Signature, error)
-	InitializeSignTransaction(req *InitializeSignTransactionRequest) (*protocol.CredentialAssertion, string, error)
-	FinalizeSignTransaction(req *protocol.ParsedCredentialAssertionData) (*solana.Transaction, bool, error)
+	Wallet(ctx context.Context, subject string) (solana.PublicKey, error)
+	Wallets(ctx context.Context, subject string) ([]*account.Wallet, error)
+	CreateWallet(ctx context.Context, subject string, label string) (*account.Wallet, error)
+	RenameWallet(ctx context.Context, subject string, index int, label string) (*account.Wallet, error)
+	CreateImportChallenge(ctx context.Context, subject string) (*ImportChallenge, error)
+	ImportWallet(ctx context.Context, req *ImportWalletRequest) (*account.Wallet, error)
+
+	SignMessage(ctx context.Context, subject string, index int, message []byte) (solana.Signature, error)
+	InitializeSignMessage(ctx context.Context, req *InitializeSignMessageRequest) (*protocol.CredentialAssertion, string, error)
+	FinalizeSignMessage(ctx context.Context, subject string, req *protocol.ParsedCredentialAssertionData) (solana.Signature, error)
+	InitializeSignOffchainMessage(ctx context.Context, req *InitializeSignOffchainMessageRequest) (*protocol.CredentialAssertion, string, *offchain.Message, error)
+	Verify(ctx context.Context, req *VerifyRequest) (*VerifyResult, error)
+	InitializeSignIn(ctx context.Context, req *InitializeSignInRequest) (*protocol.CredentialAssertion, string, *siws.Message, error)
+	VerifySignIn(ctx context.Context, req *VerifySignInRequest) (*siws.Message, int, error)
+
+	SignTransaction(ctx context.Context, subject string, index int, transaction *solana.Transaction) ([]solana.Signature, error)
+	SimulateTransaction(ctx context.Context, subject string, index int, transaction *solana.Transaction) (*Simulation, error)
+	InitializeSignTransaction(ctx context.Context, req *InitializeSignTransactionRequest) (*protocol.CredentialAssertion, string, *TransactionPreview, error)
+	FinalizeSignTransaction(ctx context.Context, subject string, req *protocol.ParsedCredentialAssertionData) (*solana.Transaction, bool, error)
+	FinalizeSubmitTransaction(ctx context.Context, subject string, req *protocol.ParsedCredentialAssertionData) (*solana.Transaction, bool, <-chan *SubmitStatus, error)
+	SubmitTransaction(ctx context.Context, transaction *solana.Transaction) (<-chan *SubmitStatus, error)
+	InitializeSignTransactions(ctx context.Context, req *InitializeSignTransactionsRequest) (*protocol.CredentialAssertion, string, []*TransactionPreview, error)
+	FinalizeSignTransactions(ctx context.Context, subject string, req *protocol.ParsedCredentialAssertionData) ([]*BatchSignResult, error)
+
+	Portfolio(ctx context.Context, subject string, index int) (*Portfolio, error)
+	BuildTransfer(ctx context.Context, req *BuildTransferRequest) (*BuiltTransaction, error)
+	BuildMemo(ctx context.Context, req *BuildMemoRequest) (*BuiltTransaction, error)
+
+	Sponsor(ctx context.Context, subject string) (*SponsorInfo, error)
+
+	Account(ctx context.Context, subject string) (*account.Account, error)
+	InitializeDeleteAccount(ctx context.Context, req *InitializeDeleteAccountRequest) (*protocol.CredentialAssertion, string, error)
+	FinalizeDeleteAccount(ctx context.Context, subject string, req *protocol.ParsedCredentialAssertionData) (string, error)
+	ListAccounts(ctx context.Context, filter account.ListFilter) ([]*account.Account, string, error)
+
+	InitializeExportKey(ctx context.Context, req *InitializeExportKeyRequest) (*protocol.CredentialAssertion, string, error)
+	FinalizeExportKey(ctx context.Context, req *FinalizeExportKeyRequest) (*ExportedKey, error)
+
+	FreezeAccount(ctx context.Context, subject string, by string, reason string) (*account.Account, error)
+	InitializeUnfreezeAccount(ctx context.Context, req *InitializeUnfreezeAccountRequest) (*protocol.CredentialAssertion, string, error)
+	FinalizeUnfreezeAccount(ctx context.Context, req *FinalizeUnfreezeAccountRequest) (*account.Account, error)
+
+	RecoverAccount(ctx context.Context, subject string, restore bool) ([]*RecoveryResult, error)
+	RecoverAccounts(ctx context.Context, restore bool) ([]*RecoveryResult, error)
 
 	CreateSession(ctx context.Context, data []byte) (string, <-chan []byte, error)
 	SessionData(ctx context.Context, session string) ([]byte, error)
@@ -38,7 +79,7 @@ type Service interface {
 }
 
 func NewService(accounts account.Repository, passkeys passkeys.Service, cfg conf.Config) (Service, error) {
-	keys, err := keys.NewGoogleKeysService(cfg.Keys.Google)
+	keys, err := keys.NewKeysService(cfg.Keys)
 	if err != nil {
 		return nil, err
 	}
@@ -46,21 +87,43 @@ func NewService(accounts account.Repository, passkeys passkeys.Service, cfg conf
 	sessionKey := cfg.Keys.Session.Key
 	privkey := ed25519.NewKeyFromSeed(sessionKey[:])
 
-	return &service{
-		accounts: accounts,
-		keys:     keys,
-		passkeys: passkeys,
-		privkey:  privkey,
-		sessions: make(map[string][]*Session),
-	}, nil
+	svc := &service{
+		accounts:   accounts,
+		keys:       keys,
+		passkeys:   passkeys,
+		privkey:    privkey,
+		sessions:   make(map[string][]*Session),
+		solana:     cfg.Solana,
+		portfolios: newPortfolioCache(cfg.Solana.PortfolioTTL),
+	}
+
+	if cfg.Solana.RPC != "" {
+		svc.rpc = rpc.New(cfg.Solana.RPC)
+	}
+
+	if cfg.Sponsor != nil {
+		sponsor, err := newSponsor(cfg.Sponsor)
+		if err != nil {
+			return nil, err
+		}
+
+		svc.sponsor = sponsor
+	}
+
+	return svc, nil
 }
 
 type service struct {
-	accounts account.Repository
-	keys     keys.Service
-	passkeys passkeys.Service
-	privkey  ed25519.PrivateKey
-	sessions map[string][]*Session
+	accounts     account.Repository
+	accountsLock sync.Mutex
+	keys         keys.Service
+	passkeys     passkeys.Service
+	privkey      ed25519.PrivateKey
+	sessions     map[string][]*Session
+	solana       conf.SolanaConfig
+	rpc          *rpc.Client
+	portfolios   *portfolioCache
+	sponsor      *sponsor
 	sync.Mutex
 }
 
@@ -71,147 +134,264 @@ type Session struct {
 	cancel context.CancelFunc
 }
 
-func (svc *service) findOrCreate(subject string) (*account.Account, error) {
+func (svc *service) create(ctx context.Context, subject string) (*account.Account, error) {
 	// TODO: find wallet from solana
 
-	key, err := svc.keys.Key()
+	key, err := svc.keys.Key(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := account.NewAccount(ctx, subject, key)
 	if err != nil {
 		return nil, err
 	}
 
-	return account.NewAccount(subject, key)
+	if err := a.Seal(ctx, key); err != nil {
+		return nil, err
+	}
+
+	return a, nil
 }
 
-func (svc *service) Wallet(subject string) (solana.PublicKey, error) {
+func (svc *service) findOrCreate(ctx context.Context, subject string) (*account.Account, error) {
 	a, err := svc.accounts.Find(subject)
 	if err != nil {
 		if !errors.Is(err, account.ErrAccountNotFound) {
-			return solana.PublicKey{}, err
+			return nil, err
 		}
 
-		newAccount, err := svc.findOrCreate(subject)
+		newAccount, err := svc.create(ctx, subject)
 		if err != nil {
-			return solana.PublicKey{}, err
+			return nil, err
 		}
 
 		if err := svc.accounts.Save(newAccount); err != nil {
-			return solana.PublicKey{}, err
+			return nil, err
 		}
 
 		a = newAccount
 	}
 
-	return a.Wallet(), nil
+	if err := svc.migrate(ctx, a); err != nil {
+		return nil, err
+	}
+
+	return a, nil
 }
 
-func (svc *service) SignMessage(subject string, message []byte) (solana.Signature, error) {
-	a, err := svc.accounts.Find(subject)
+// migrate seals a legacy account that was persisted with a plaintext private key.
+func (svc *service) migrate(ctx context.Context, a *account.Account) error {
+	if a.Sealed() {
+		return nil
+	}
+
+	key, err := svc.keys.Key(ctx, a.KeyVersion)
 	if err != nil {
-		return solana.Signature{}, err
+		return err
 	}
 
-	privkey := solana.PrivateKey(a.PrivateKey)
+	sealed := a.Clone()
+	if err := sealed.Seal(ctx, key); err != nil {
+		return err
+	}
 
-	return privkey.Sign(message)
+	return svc.accounts.Save(sealed)
 }
 
-func (svc *service) InitializeSignMessage(req *InitializeSignMessageRequest) (*protocol.CredentialAssertion, string, error) {
-	r := &passkeys.InitializeTransactionRequest{
-		UserID:          req.UserID,
-		TransactionID:   req.TransactionID,
-		TransactionData: sha256.Sum256(req.Message),
+// finalize completes the passkey ceremony and consumes the cached transaction
+// it was bound to. The transaction must have been started by subject and by
+// the user the passkey token names; it is only consumed once both match.
+func (svc *service) finalize(subject string, req *protocol.ParsedCredentialAssertionData) (*account.Transaction, error) {
+	tokenStr, err := svc.passkeys.FinalizeTransaction(req)
+	if err != nil {
+		return nil, err
 	}
 
-	opts, mediation, err := svc.passkeys.InitializeTransaction(r)
+	token, err := svc.passkeys.VerifyToken(tokenStr)
 	if err != nil {
-		return nil, "", err
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid type")
+	}
+
+	tid, ok := claims["trans"].(string)
+	if !ok {
+		return nil, errors.New("invalid type")
 	}
 
-	sig, err := svc.SignMessage(req.Subject, req.Message)
+	id, err := account.ParseTransactionID(tid)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
-	t, err := account.NewSignMessageTransaction(req.TransactionID, req.Message)
+	user, _ := claims.GetSubject()
+
+	bound := func(t *account.Transaction) bool {
+		return t.Subject == subject && t.UserID != "" && t.UserID == user
+	}
+
+	t, err := svc.accounts.FindTransactionByID(id)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
-	t.Message.Signature = sig
+	if !bound(t) {
+		return nil, account.ErrTransactionMismatch
+	}
 
-	if err := svc.accounts.CacheTransaction(t, 120*time.Second); err != nil {
-		return nil, "", err
+	t, err = svc.accounts.RemoveTransactionByID(id)
+	if err != nil {
+		return nil, err
 	}
 
-	return opts, mediation, nil
-}
+	// the transaction may have been replaced in between
+	if !bound(t) {
+		return nil, account.ErrTransactionMismatch
+	}
 
-func (svc *service) FinalizeSignMessage(req *protocol.ParsedCredentialAssertionData) (solana.Signature, error) {
-	var sig solana.Signature
+	return t, nil
+}
 
-	tokenStr, err := svc.passkeys.FinalizeTransaction(req)
+// findActive finds an account that is allowed to sign.
+func (svc *service) findActive(subject string) (*account.Account, error) {
+	a, err := svc.accounts.Find(subject)
 	if err != nil {
-		return sig, err
+		return nil, err
 	}
 
-	token, err := svc.passkeys.VerifyToken(tokenStr)
+	if a.IsFrozen() {
+		return nil, account.ErrAccountFrozen
+	}
+
+	return a, nil
+}
+
+// privateKey unwraps a wallet private key for a single signing operation.
+func (svc *service) privateKey(ctx context.Context, a *account.Account, index int) (solana.PrivateKey, error) {
+	w, err := a.FindWallet(index)
 	if err != nil {
-		return sig, err
+		return nil, err
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return sig, errors.New("invalid type")
+	if w.EncryptedKey == nil {
+		if err := svc.migrate(ctx, a); err != nil {
+			return nil, err
+		}
+
+		if w.PrivateKey == nil {
+			return nil, errors.New("private key not found")
+		}
+
+		return solana.PrivateKey(w.PrivateKey), nil
 	}
 
-	tid, ok := claims["trans"].(string)
-	if !ok {
-		return sig, errors.New("invalid type")
+	key, err := svc.keys.Key(ctx, w.EncryptedKey.KeyVersion)
+	if err != nil {
+		return nil, err
 	}
 
-	id, err := account.ParseTransactionID(tid)
+	privkey, err := a.Unseal(ctx, key, index)
 	if err != nil {
-		return sig, err
+		return nil, err
 	}
 
-	t, err := svc.accounts.RemoveTransactionByID(id)
+	return solana.PrivateKey(privkey), nil
+}
+
+func (svc *service) Wallet(ctx context.Context, subject string) (solana.PublicKey, error) {
+	a, err := svc.findOrCreate(ctx, subject)
 	if err != nil {
-		return sig, err
+		return solana.PublicKey{}, err
 	}
 
-	sig = t.Message.Signature
+	return a.Wallet(), nil
+}
 
-	return sig, nil
+func (svc *service) Wallets(ctx context.Context, subject string) ([]*account.Wallet, error) {
+	a, err := svc.findOrCreate(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.Wallets, nil
 }
 
-func (svc *service) SignTransaction(subject string, transaction *solana.Transaction) ([]solana.Signature, error) {
-	a, err := svc.accounts.Find(subject)
+func (svc *service) CreateWallet(ctx context.Context, subject string, label string) (*account.Wallet, error) {
+	svc.accountsLock.Lock()
+	defer svc.accountsLock.Unlock()
+
+	a, err := svc.findOrCreate(ctx, subject)
 	if err != nil {
 		return nil, err
 	}
 
-	getter := func(key solana.PublicKey) *solana.PrivateKey {
-		if key.Equals(a.Wallet()) {
-			privkey := solana.PrivateKey(a.PrivateKey)
-			return &privkey
-		}
+	if a.IsFrozen() {
+		return nil, account.ErrAccountFrozen
+	}
 
-		return nil
+	key, err := svc.keys.Key(ctx, a.KeyVersion)
+	if err != nil {
+		return nil, err
 	}
 
-	return transaction.Sign(getter)
+	updated := a.Clone()
+
+	w, err := updated.NewWallet(ctx, key, label)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := updated.Seal(ctx, key); err != nil {
+		return nil, err
+	}
+
+	if err := svc.accounts.Save(updated); err != nil {
+		return nil, err
+	}
+
+	return w, nil
 }
 
-func (svc *service) InitializeSignTransaction(req *InitializeSignTransactionRequest) (*protocol.CredentialAssertion, string, error) {
-	data, err := req.Transaction.MarshalBinary()
+func (svc *service) RenameWallet(ctx context.Context, subject string, index int, label string) (*account.Wallet, error) {
+	svc.accountsLock.Lock()
+	defer svc.accountsLock.Unlock()
+
+	a, err := svc.findActive(subject)
 	if err != nil {
+		return nil, err
+	}
+
+	updated := a.Clone()
+
+	w, err := updated.RenameWallet(index, label)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := svc.accounts.Save(updated); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (svc *service) Account(ctx context.Context, subject string) (*account.Account, error) {
+	return svc.accounts.Find(subject)
+}
+
+func (svc *service) InitializeDeleteAccount(ctx context.Context, req *InitializeDeleteAccountRequest) (*protocol.CredentialAssertion, string, error) {
+	if _, err := svc.findActive(req.Subject); err != nil {
 		return nil, "", err
 	}
 
 	r := &passkeys.InitializeTransactionRequest{
 		UserID:          req.UserID,
 		TransactionID:   req.TransactionID,
-		TransactionData: sha256.Sum256(data),
+		TransactionData: sha256.Sum256([]byte("wallet:delete:" + req.Subject)),
 	}
 
 	opts, mediation, err := svc.passkeys.InitializeTransaction(r)
@@ -219,17 +399,124 @@ func (svc *service) InitializeSignTransaction(req *InitializeSignTransactionRequ
 		return nil, "", err
 	}
 
-	sigs, err := svc.SignTransaction(req.Subject, req.Transaction)
+	t, err := account.NewDeleteAccountTransaction(req.TransactionID, req.Subject)
+	if err != nil {
+		return nil, "", err
+	}
+
+	t.UserID = req.UserID
+
+	if err := svc.accounts.CacheTransaction(t, 120*time.Second); err != nil {
+		return nil, "", err
+	}
+
+	return opts, mediation, nil
+}
+
+func (svc *service) FinalizeDeleteAccount(ctx context.Context, subject string, req *protocol.ParsedCredentialAssertionData) (string, error) {
+	t, err := svc.finalize(subject, req)
+	if err != nil {
+		return "", err
+	}
+
+	if t.Deletion == nil {
+		return "", errors.New("invalid transaction")
+	}
+
+	if err := svc.deleteAccount(subject); err != nil {
+		return "", err
+	}
+
+	return subject, nil
+}
+
+// deleteAccount erases the account and records the erasure. A frozen account
+// is kept until an admin unfreezes it.
+func (svc *service) deleteAccount(subject string) error {
+	svc.accountsLock.Lock()
+	defer svc.accountsLock.Unlock()
+
+	a, err := svc.findActive(subject)
+	if err != nil {
+		return err
+	}
+
+	if err := svc.accounts.Delete(subject); err != nil {
+		return err
+	}
+
+	e := account.NewAuditEvent(subject, account.AuditAccountDeleted)
+	for _, w := range a.Wallets {
+		e.Details["wallet:"+strconv.Itoa(w.Index)] = w.PublicKey.String()
+	}
+
+	return svc.accounts.RecordAudit(e)
+}
+
+func (svc *service) ListAccounts(ctx context.Context, filter account.ListFilter) ([]*account.Account, string, error) {
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 50
+	}
+
+	return svc.accounts.List(filter)
+}
+
+func (svc *service) SignMessage(ctx context.Context, subject string, index int, message []byte) (solana.Signature, error) {
+	a, err := svc.findActive(subject)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	w, err := a.FindWallet(index)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	if err := checkMessage(w.PublicKey, message); err != nil {
+		return solana.Signature{}, err
+	}
+
+	privkey, err := svc.privateKey(ctx, a, index)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	return privkey.Sign(message)
+}
+
+func (svc *service) InitializeSignMessage(ctx context.Context, req *InitializeSignMessageRequest) (*protocol.CredentialAssertion, string, error) {
+	a, err := svc.findActive(req.Subject)
+	if err != nil {
+		return nil, "", err
+	}
+
+	w, err := a.FindWallet(req.Wallet)
 	if err != nil {
 		return nil, "", err
 	}
 
-	t, err := account.NewSignTransaction(req.TransactionID, req.Transaction, req.Versioned)
+	if err := checkMessage(w.PublicKey, req.Message); err != nil {
+		return nil, "", err
+	}
+
+	// only the unsigned message is cached; signing waits for the passkey
+	t, err := account.NewSignMessageTransaction(req.TransactionID, req.Subject, req.Wallet, req.Message)
+	if err != nil {
+		return nil, "", err
+	}
+
+	r := &passkeys.InitializeTransactionRequest{
+		UserID:          req.UserID,
+		TransactionID:   req.TransactionID,
+		TransactionData: t.Hash,
+	}
+
+	opts, mediation, err := svc.passkeys.InitializeTransaction(r)
 	if err != nil {
 		return nil, "", err
 	}
 
-	t.Transaction.Signatures = sigs
+	t.UserID = req.UserID
 
 	if err := svc.accounts.CacheTransaction(t, 120*time.Second); err != nil {
 		return nil, "", err
@@ -238,40 +525,150 @@ func (svc *service) InitializeSignTransaction(req *InitializeSignTransactionRequ
 	return opts, mediation, nil
 }
 
-func (svc *service) FinalizeSignTransaction(req *protocol.ParsedCredentialAssertionData) (*solana.Transaction, bool, error) {
-	tokenStr, err := svc.passkeys.FinalizeTransaction(req)
+func (svc *service) FinalizeSignMessage(ctx context.Context, subject string, req *protocol.ParsedCredentialAssertionData) (solana.Signature, error) {
+	var sig solana.Signature
+
+	t, err := svc.finalize(subject, req)
 	if err != nil {
-		return nil, false, err
+		return sig, err
 	}
 
-	token, err := svc.passkeys.VerifyToken(tokenStr)
+	if t.Message == nil {
+		return sig, errors.New("invalid transaction")
+	}
+
+	if err := t.VerifyPayload(); err != nil {
+		return sig, err
+	}
+
+	return svc.SignMessage(ctx, t.Subject, t.Message.Wallet, t.Message.Message)
+}
+
+func (svc *service) SignTransaction(ctx context.Context, subject string, index int, transaction *solana.Transaction) ([]solana.Signature, error) {
+	a, err := svc.findActive(subject)
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return nil, false, errors.New("invalid type")
+	w, err := a.FindWallet(index)
+	if err != nil {
+		return nil, err
 	}
 
-	tid, ok := claims["trans"].(string)
-	if !ok {
-		return nil, false, errors.New("invalid type")
+	privkey, err := svc.privateKey(ctx, a, index)
+	if err != nil {
+		return nil, err
 	}
 
-	id, err := account.ParseTransactionID(tid)
+	getter := func(key solana.PublicKey) *solana.PrivateKey {
+		if key.Equals(w.PublicKey) {
+			return &privkey
+		}
+
+		return nil
+	}
+
+	return transaction.Sign(getter)
+}
+
+func (svc *service) InitializeSignTransaction(ctx context.Context, req *InitializeSignTransactionRequest) (*protocol.CredentialAssertion, string, *TransactionPreview, error) {
+	a, err := svc.findActive(req.Subject)
 	if err != nil {
-		return nil, false, err
+		return nil, "", nil, err
+	}
+
+	w, err := a.FindWallet(req.Wallet)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	if req.Sponsored {
+		if _, err := svc.checkSponsorship(req.Subject, req.Transaction); err != nil {
+			return nil, "", nil, err
+		}
+	}
+
+	// only the unsigned transaction is cached; signing waits for the passkey
+	t, err := account.NewSignTransaction(req.TransactionID, req.Subject, req.Wallet, req.Transaction, req.Versioned)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	t.Transaction.Sponsored = req.Sponsored
+
+	summary, err := inspect.Inspect(req.Transaction)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	t.Summary = summary
+
+	preview := &TransactionPreview{
+		Summary: summary,
+	}
+
+	if svc.rpc != nil && svc.solana.Simulate {
+		simulation, err := svc.simulate(ctx, w.PublicKey, req.Transaction)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		if simulation.Err != "" {
+			return nil, "", nil, fmt.Errorf("%w: %s", ErrSimulationFailed, simulation.Err)
+		}
+
+		preview.Simulation = simulation
+	}
+
+	r := &passkeys.InitializeTransactionRequest{
+		UserID:          req.UserID,
+		TransactionID:   req.TransactionID,
+		TransactionData: t.Hash,
+	}
+
+	opts, mediation, err := svc.passkeys.InitializeTransaction(r)
+	if err != nil {
+		return nil, "", nil, err
 	}
 
-	t, err := svc.accounts.RemoveTransactionByID(id)
+	t.UserID = req.UserID
+
+	if err := svc.accounts.CacheTransaction(t, 120*time.Second); err != nil {
+		return nil, "", nil, err
+	}
+
+	return opts, mediation, preview, nil
+}
+
+func (svc *service) FinalizeSignTransaction(ctx context.Context, subject string, req *protocol.ParsedCredentialAssertionData) (*solana.Transaction, bool, error) {
+	t, err := svc.finalize(subject, req)
 	if err != nil {
 		return nil, false, err
 	}
 
+	if t.Transaction == nil {
+		return nil, false, errors.New("invalid transaction")
+	}
+
+	if err := t.VerifyPayload(); err != nil {
+		return nil, false, err
+	}
+
 	tx := t.Transaction.Transaction
 	versioned := t.Transaction.Versioned
 
+	if t.Transaction.Sponsored {
+		if err := svc.signSponsored(ctx, t.Subject, t.Transaction.Wallet, tx); err != nil {
+			return nil, false, err
+		}
+
+		return tx, versioned, nil
+	}
+
+	if _, err := svc.SignTransaction(ctx, t.Subject, t.Transaction.Wallet, tx); err != nil {
+		return nil, false, err
+	}
+
 	return tx, versioned, nil
 }
 
@@ -0,0 +1,114 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+
+	"github.com/flarexio/wallet/siws"
+)
+
+var ErrInvalidSignIn = errors.New("invalid sign-in")
+
+const siwsVersion = "1"
+
+// InitializeSignIn renders a SIWS message for the wallet and hands it to the
+// passkey-gated message flow; FinalizeSignMessage completes it.
+func (svc *service) InitializeSignIn(ctx context.Context, req *InitializeSignInRequest) (*protocol.CredentialAssertion, string, *siws.Message, error) {
+	if req.Domain == "" || req.Nonce == "" {
+		return nil, "", nil, errors.New("domain and nonce are required")
+	}
+
+	a, err := svc.findActive(req.Subject)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	w, err := a.FindWallet(req.Wallet)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	m := &siws.Message{
+		Domain:         req.Domain,
+		Address:        w.PublicKey,
+		Statement:      req.Statement,
+		URI:            req.URI,
+		Version:        req.Version,
+		ChainID:        req.ChainID,
+		Nonce:          req.Nonce,
+		IssuedAt:       req.IssuedAt,
+		ExpirationTime: req.ExpirationTime,
+		NotBefore:      req.NotBefore,
+		RequestID:      req.RequestID,
+		Resources:      req.Resources,
+	}
+
+	if m.Version == "" {
+		m.Version = siwsVersion
+	}
+
+	if m.IssuedAt.IsZero() {
+		m.IssuedAt = time.Now().UTC().Truncate(time.Second)
+	}
+
+	if err := m.Validate(time.Now()); err != nil {
+		return nil, "", nil, fmt.Errorf("%w: %w", ErrInvalidSignIn, err)
+	}
+
+	opts, mediation, err := svc.InitializeSignMessage(ctx, &InitializeSignMessageRequest{
+		Subject:       req.Subject,
+		UserID:        req.UserID,
+		TransactionID: req.TransactionID,
+		Wallet:        req.Wallet,
+		Message:       []byte(m.String()),
+	})
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return opts, mediation, m, nil
+}
+
+// VerifySignIn checks a signed SIWS message against the account's wallets and,
+// when given, the domain and nonce the relying party expects.
+func (svc *service) VerifySignIn(ctx context.Context, req *VerifySignInRequest) (*siws.Message, int, error) {
+	a, err := svc.accounts.Find(req.Subject)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	m, err := siws.Verify(req.Message, req.Signature)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrInvalidSignIn, err)
+	}
+
+	index := -1
+	for _, w := range a.Wallets {
+		if w.PublicKey.Equals(m.Address) {
+			index = w.Index
+			break
+		}
+	}
+
+	if index < 0 {
+		return nil, 0, fmt.Errorf("%w: address does not belong to account", ErrInvalidSignIn)
+	}
+
+	if err := m.Validate(time.Now()); err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrInvalidSignIn, err)
+	}
+
+	if req.Domain != "" && m.Domain != req.Domain {
+		return nil, 0, fmt.Errorf("%w: domain mismatch", ErrInvalidSignIn)
+	}
+
+	if req.Nonce != "" && m.Nonce != req.Nonce {
+		return nil, 0, fmt.Errorf("%w: nonce mismatch", ErrInvalidSignIn)
+	}
+
+	return m, index, nil
+}
@@ -0,0 +1,75 @@
+package wallet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flarexio/wallet/siws"
+)
+
+func TestVerifySignIn(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	address, err := svc.Wallet(ctx, "user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	m := &siws.Message{
+		Domain:         "example.com",
+		Address:        address,
+		Version:        "1",
+		ChainID:        "mainnet",
+		Nonce:          "a1b2c3d4e5",
+		IssuedAt:       time.Now().UTC().Truncate(time.Second),
+		ExpirationTime: time.Now().UTC().Add(time.Hour).Truncate(time.Second),
+	}
+
+	text := m.String()
+
+	sig, err := svc.SignMessage(ctx, "user", 0, []byte(text))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	req := &VerifySignInRequest{
+		Subject:   "user",
+		Message:   text,
+		Signature: sig,
+		Domain:    "example.com",
+		Nonce:     "a1b2c3d4e5",
+	}
+
+	verified, index, err := svc.VerifySignIn(ctx, req)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(0, index)
+	assert.Equal(address, verified.Address)
+
+	req.Nonce = "f6g7h8i9j0"
+	_, _, err = svc.VerifySignIn(ctx, req)
+	assert.ErrorIs(err, ErrInvalidSignIn)
+
+	// signed by the wallet of another account
+	if _, err := svc.Wallet(ctx, "other"); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	req.Subject = "other"
+	req.Nonce = ""
+	_, _, err = svc.VerifySignIn(ctx, req)
+	assert.ErrorIs(err, ErrInvalidSignIn)
+}
@@ -0,0 +1,247 @@
+package wallet
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/flarexio/wallet/inspect"
+)
+
+var (
+	ErrSimulationFailed  = errors.New("simulation failed")
+	ErrSolanaUnavailable = errors.New("solana rpc is not configured")
+)
+
+// token account layout: mint, owner, amount, delegate, state, ...
+const tokenAccountSize = 165
+
+const tokenAccountFrozen byte = 2
+
+type TransactionPreview struct {
+	Summary    *inspect.Summary `json:"summary"`
+	Simulation *Simulation      `json:"simulation,omitempty"`
+}
+
+// Simulation reports what a transaction would do to the wallet if it were
+// executed now. Deltas are signed, in lamports or base token units.
+type Simulation struct {
+	Wallet        solana.PublicKey `json:"wallet"`
+	Err           string           `json:"error,omitempty"`
+	Logs          []string         `json:"logs"`
+	UnitsConsumed uint64           `json:"units_consumed"`
+	SOLDelta      int64            `json:"sol_delta"`
+	TokenDeltas   []*TokenDelta    `json:"token_deltas"`
+}
+
+type TokenDelta struct {
+	Account  solana.PublicKey `json:"account"`
+	Mint     solana.PublicKey `json:"mint"`
+	Decimals uint8            `json:"decimals"`
+	Before   uint64           `json:"before"`
+	After    uint64           `json:"after"`
+	Delta    int64            `json:"delta"`
+}
+
+type tokenAccount struct {
+	mint   solana.PublicKey
+	owner  solana.PublicKey
+	amount uint64
+	frozen bool
+}
+
+func parseTokenAccount(acc *rpc.Account) (*tokenAccount, bool) {
+	if acc == nil || acc.Data == nil {
+		return nil, false
+	}
+
+	if !acc.Owner.Equals(solana.TokenProgramID) && !acc.Owner.Equals(solana.Token2022ProgramID) {
+		return nil, false
+	}
+
+	// Token-2022 accounts may carry extensions after the base layout
+	data := acc.Data.GetBinary()
+	if len(data) < tokenAccountSize {
+		return nil, false
+	}
+
+	return &tokenAccount{
+		mint:   solana.PublicKeyFromBytes(data[0:32]),
+		owner:  solana.PublicKeyFromBytes(data[32:64]),
+		amount: binary.LittleEndian.Uint64(data[64:72]),
+		frozen: data[108] == tokenAccountFrozen,
+	}, true
+}
+
+func (svc *service) SimulateTransaction(ctx context.Context, subject string, index int, transaction *solana.Transaction) (*Simulation, error) {
+	if svc.rpc == nil {
+		return nil, ErrSolanaUnavailable
+	}
+
+	a, err := svc.accounts.Find(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := a.FindWallet(index)
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.simulate(ctx, w.PublicKey, transaction)
+}
+
+// padSignatures returns a copy of tx with a signature slot for every
+// required signer. The validator rejects a transaction whose signature count
+// differs from its header, and an unsigned one usually has none.
+func padSignatures(tx *solana.Transaction) *solana.Transaction {
+	padded := *tx
+	padded.Signatures = make([]solana.Signature, tx.Message.Header.NumRequiredSignatures)
+	copy(padded.Signatures, tx.Signatures)
+
+	return &padded
+}
+
+// simulate runs the unsigned transaction against the configured cluster and
+// diffs the wallet and every writable token account it owns.
+func (svc *service) simulate(ctx context.Context, wallet solana.PublicKey, tx *solana.Transaction) (*Simulation, error) {
+	commitment := rpc.CommitmentType(svc.solana.Commitment)
+
+	addresses := []solana.PublicKey{wallet}
+	for _, key := range tx.Message.AccountKeys {
+		if key.Equals(wallet) {
+			continue
+		}
+
+		if writable, err := tx.Message.IsWritable(key); err != nil || !writable {
+			continue
+		}
+
+		addresses = append(addresses, key)
+	}
+
+	pre, err := svc.rpc.GetMultipleAccountsWithOpts(ctx, addresses, &rpc.GetMultipleAccountsOpts{
+		Encoding:   solana.EncodingBase64,
+		Commitment: commitment,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := svc.rpc.SimulateTransactionWithOpts(ctx, padSignatures(tx), &rpc.SimulateTransactionOpts{
+		SigVerify:              false,
+		ReplaceRecentBlockhash: true,
+		Commitment:             commitment,
+		Accounts: &rpc.SimulateTransactionAccountsOpts{
+			Encoding:  solana.EncodingBase64,
+			Addresses: addresses,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := resp.Value
+	if result == nil {
+		return nil, errors.New("empty simulation result")
+	}
+
+	simulation := &Simulation{
+		Wallet:      wallet,
+		Logs:        result.Logs,
+		TokenDeltas: make([]*TokenDelta, 0),
+	}
+
+	if result.UnitsConsumed != nil {
+		simulation.UnitsConsumed = *result.UnitsConsumed
+	}
+
+	if result.Err != nil {
+		reason, err := json.Marshal(result.Err)
+		if err != nil {
+			reason = []byte(fmt.Sprint(result.Err))
+		}
+
+		simulation.Err = string(reason)
+		return simulation, nil
+	}
+
+	if len(pre.Value) != len(addresses) || len(result.Accounts) != len(addresses) {
+		return nil, errors.New("unexpected number of accounts")
+	}
+
+	var before, after uint64
+	if pre.Value[0] != nil {
+		before = pre.Value[0].Lamports
+	}
+
+	if result.Accounts[0] != nil {
+		after = result.Accounts[0].Lamports
+	}
+
+	simulation.SOLDelta = int64(after - before)
+
+	mints := make([]solana.PublicKey, 0)
+	for i := 1; i < len(addresses); i++ {
+		preToken, preOK := parseTokenAccount(pre.Value[i])
+		postToken, postOK := parseTokenAccount(result.Accounts[i])
+
+		// a token account may be created or closed by the transaction
+		token := postToken
+		if !postOK {
+			token = preToken
+		}
+
+		if !preOK && !postOK || !token.owner.Equals(wallet) {
+			continue
+		}
+
+		delta := &TokenDelta{
+			Account: addresses[i],
+			Mint:    token.mint,
+		}
+
+		if preOK {
+			delta.Before = preToken.amount
+		}
+
+		if postOK {
+			delta.After = postToken.amount
+		}
+
+		delta.Delta = int64(delta.After - delta.Before)
+
+		simulation.TokenDeltas = append(simulation.TokenDeltas, delta)
+		mints = append(mints, token.mint)
+	}
+
+	if len(mints) == 0 {
+		return simulation, nil
+	}
+
+	mintAccounts, err := svc.rpc.GetMultipleAccountsWithOpts(ctx, mints, &rpc.GetMultipleAccountsOpts{
+		Encoding:   solana.EncodingBase64,
+		Commitment: commitment,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, mint := range mintAccounts.Value {
+		if i >= len(simulation.TokenDeltas) || mint == nil || mint.Data == nil {
+			continue
+		}
+
+		// mint layout: mint authority option (36), supply (8), decimals
+		if data := mint.Data.GetBinary(); len(data) > 44 {
+			simulation.TokenDeltas[i].Decimals = data[44]
+		}
+	}
+
+	return simulation, nil
+}
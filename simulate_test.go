@@ -0,0 +1,242 @@
+package wallet
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTokenAccount(t *testing.T) {
+	assert := assert.New(t)
+
+	mint := solana.NewWallet().PublicKey()
+	owner := solana.NewWallet().PublicKey()
+
+	data := make([]byte, tokenAccountSize)
+	copy(data[0:32], mint[:])
+	copy(data[32:64], owner[:])
+	binary.LittleEndian.PutUint64(data[64:72], 1_000)
+
+	acc := &rpc.Account{
+		Owner: solana.TokenProgramID,
+		Data:  rpc.DataBytesOrJSONFromBytes(data),
+	}
+
+	token, ok := parseTokenAccount(acc)
+	if !assert.True(ok) {
+		return
+	}
+
+	assert.Equal(mint, token.mint)
+	assert.Equal(owner, token.owner)
+	assert.Equal(uint64(1_000), token.amount)
+
+	acc.Owner = solana.SystemProgramID
+	_, ok = parseTokenAccount(acc)
+	assert.False(ok)
+}
+
+func TestPadSignatures(t *testing.T) {
+	assert := assert.New(t)
+
+	payer := solana.NewWallet().PublicKey()
+	signer := solana.NewWallet().PublicKey()
+
+	tx := memoTransaction(t, payer, signer)
+	assert.Empty(tx.Signatures)
+
+	padded := padSignatures(tx)
+
+	assert.Len(padded.Signatures, 2)
+	assert.Equal(int(padded.Message.Header.NumRequiredSignatures), len(padded.Signatures))
+	assert.Empty(tx.Signatures)
+
+	bs, err := padded.MarshalBinary()
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	decoded, err := solana.TransactionFromBytes(bs)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(decoded.Signatures, 2)
+}
+
+// TestSimulateUnsigned checks what reaches the RPC for an unpadded,
+// unsigned transaction, without a validator.
+func TestSimulateUnsigned(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	var (
+		sent *solana.Transaction
+		opts map[string]any
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		result := `{"context":{"slot":1},"value":[null]}`
+		if req.Method == "simulateTransaction" {
+			var data string
+			json.Unmarshal(req.Params[0], &data)
+			json.Unmarshal(req.Params[1], &opts)
+
+			sent, _ = solana.TransactionFromBase64(data)
+
+			result = `{"context":{"slot":1},"value":{"err":"AccountNotFound","logs":[]}}`
+		}
+
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+	}))
+	defer server.Close()
+
+	svc := newTestService(t)
+	svc.rpc = rpc.New(server.URL)
+
+	wallet := solana.NewWallet().PublicKey()
+	tx := memoTransaction(t, wallet, wallet)
+
+	simulation, err := svc.simulate(ctx, wallet, tx)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.NotEmpty(simulation.Err)
+
+	if !assert.NotNil(sent) {
+		return
+	}
+
+	assert.Len(sent.Signatures, int(tx.Message.Header.NumRequiredSignatures))
+	assert.Empty(tx.Signatures)
+
+	assert.Equal(true, opts["replaceRecentBlockhash"])
+	assert.NotContains(opts, "sigVerify")
+}
+
+func airdrop(ctx context.Context, client *rpc.Client, to solana.PublicKey) error {
+	sig, err := client.RequestAirdrop(ctx, to, solana.LAMPORTS_PER_SOL, rpc.CommitmentConfirmed)
+	if err != nil {
+		return err
+	}
+
+	for range 30 {
+		statuses, err := client.GetSignatureStatuses(ctx, false, sig)
+		if err == nil && statuses.Value[0] != nil && statuses.Value[0].ConfirmationStatus != "" &&
+			statuses.Value[0].ConfirmationStatus != rpc.ConfirmationStatusProcessed {
+			return nil
+		}
+
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	return errors.New("airdrop not confirmed")
+}
+
+// TestSimulateTransaction runs against a local solana-test-validator, e.g.
+// SOLANA_TEST_VALIDATOR_RPC=http://127.0.0.1:8899
+func TestSimulateTransaction(t *testing.T) {
+	endpoint := os.Getenv("SOLANA_TEST_VALIDATOR_RPC")
+	if endpoint == "" {
+		t.Skip("SOLANA_TEST_VALIDATOR_RPC is not set")
+	}
+
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	svc.rpc = rpc.New(endpoint)
+	svc.solana.Commitment = "confirmed"
+	svc.solana.Simulate = true
+
+	from, err := svc.Wallet(ctx, "user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if err := airdrop(ctx, svc.rpc, from); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	latest, err := svc.rpc.GetLatestBlockhash(ctx, rpc.CommitmentConfirmed)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	to := solana.NewWallet().PublicKey()
+
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(1_000_000, from, to).Build(),
+		},
+		latest.Value.Blockhash,
+		solana.TransactionPayer(from),
+	)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	simulation, err := svc.SimulateTransaction(ctx, "user", 0, tx)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Empty(simulation.Err)
+	assert.NotEmpty(simulation.Logs)
+	assert.Positive(simulation.UnitsConsumed)
+	assert.Equal(int64(-1_005_000), simulation.SOLDelta)
+
+	tx, err = solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(10*solana.LAMPORTS_PER_SOL, from, to).Build(),
+		},
+		latest.Value.Blockhash,
+		solana.TransactionPayer(from),
+	)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	simulation, err = svc.SimulateTransaction(ctx, "user", 0, tx)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.NotEmpty(simulation.Err)
+}
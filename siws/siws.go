@@ -0,0 +1,302 @@
+// Package siws builds and verifies Sign-In With Solana messages, the
+// CAIP-122 text format used by the wallet-standard solana:signIn feature.
+package siws
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+var (
+	ErrMalformedMessage = errors.New("malformed sign-in message")
+	ErrInvalidNonce     = errors.New("invalid nonce")
+	ErrExpired          = errors.New("sign-in message expired")
+	ErrNotYetValid      = errors.New("sign-in message not yet valid")
+	ErrInvalidSignature = errors.New("invalid sign-in signature")
+)
+
+const (
+	header = " wants you to sign in with your Solana account:"
+
+	minNonceLength = 8
+
+	// tolerated clock difference for issued at and not before
+	clockSkew = 5 * time.Minute
+)
+
+const (
+	fieldURI            = "URI: "
+	fieldVersion        = "Version: "
+	fieldChainID        = "Chain ID: "
+	fieldNonce          = "Nonce: "
+	fieldIssuedAt       = "Issued At: "
+	fieldExpirationTime = "Expiration Time: "
+	fieldNotBefore      = "Not Before: "
+	fieldRequestID      = "Request ID: "
+	fieldResources      = "Resources:"
+)
+
+var fields = []string{
+	fieldURI,
+	fieldVersion,
+	fieldChainID,
+	fieldNonce,
+	fieldIssuedAt,
+	fieldExpirationTime,
+	fieldNotBefore,
+	fieldRequestID,
+	fieldResources,
+}
+
+type Message struct {
+	Domain         string           `json:"domain"`
+	Address        solana.PublicKey `json:"address"`
+	Statement      string           `json:"statement,omitempty"`
+	URI            string           `json:"uri,omitempty"`
+	Version        string           `json:"version,omitempty"`
+	ChainID        string           `json:"chain_id,omitempty"`
+	Nonce          string           `json:"nonce,omitempty"`
+	IssuedAt       time.Time        `json:"issued_at,omitzero"`
+	ExpirationTime time.Time        `json:"expiration_time,omitzero"`
+	NotBefore      time.Time        `json:"not_before,omitzero"`
+	RequestID      string           `json:"request_id,omitempty"`
+	Resources      []string         `json:"resources,omitempty"`
+}
+
+// String renders the message in the order fixed by the specification;
+// absent optional fields are omitted.
+func (m *Message) String() string {
+	var b strings.Builder
+
+	b.WriteString(m.Domain + header + "\n")
+	b.WriteString(m.Address.String())
+
+	if m.Statement != "" {
+		b.WriteString("\n\n" + m.Statement)
+	}
+
+	lines := make([]string, 0)
+	appendField := func(name, value string) {
+		if value != "" {
+			lines = append(lines, name+value)
+		}
+	}
+
+	appendTime := func(name string, value time.Time) {
+		if !value.IsZero() {
+			lines = append(lines, name+value.UTC().Format(time.RFC3339Nano))
+		}
+	}
+
+	appendField(fieldURI, m.URI)
+	appendField(fieldVersion, m.Version)
+	appendField(fieldChainID, m.ChainID)
+	appendField(fieldNonce, m.Nonce)
+	appendTime(fieldIssuedAt, m.IssuedAt)
+	appendTime(fieldExpirationTime, m.ExpirationTime)
+	appendTime(fieldNotBefore, m.NotBefore)
+	appendField(fieldRequestID, m.RequestID)
+
+	if len(m.Resources) > 0 {
+		lines = append(lines, fieldResources)
+		for _, r := range m.Resources {
+			lines = append(lines, "- "+r)
+		}
+	}
+
+	if len(lines) > 0 {
+		b.WriteString("\n\n" + strings.Join(lines, "\n"))
+	}
+
+	return b.String()
+}
+
+// Parse reads a message produced by String or by another SIWS implementation.
+func Parse(text string) (*Message, error) {
+	domain, rest, ok := strings.Cut(text, header+"\n")
+	if !ok || domain == "" || strings.ContainsAny(domain, " \n") {
+		return nil, fmt.Errorf("%w: header", ErrMalformedMessage)
+	}
+
+	address, rest, _ := strings.Cut(rest, "\n")
+
+	pubkey, err := solana.PublicKeyFromBase58(address)
+	if err != nil {
+		return nil, fmt.Errorf("%w: address: %w", ErrMalformedMessage, err)
+	}
+
+	m := &Message{
+		Domain:  domain,
+		Address: pubkey,
+	}
+
+	if rest == "" {
+		return m, nil
+	}
+
+	// address, statement and fields are separated by a blank line
+	rest, ok = strings.CutPrefix(rest, "\n")
+	if !ok {
+		return nil, fmt.Errorf("%w: missing separator", ErrMalformedMessage)
+	}
+
+	blocks := strings.Split(rest, "\n\n")
+	if len(blocks) > 2 {
+		return nil, fmt.Errorf("%w: unexpected section", ErrMalformedMessage)
+	}
+
+	if !isField(blocks[0]) {
+		m.Statement = blocks[0]
+		if m.Statement == "" || strings.Contains(m.Statement, "\n") {
+			return nil, fmt.Errorf("%w: statement", ErrMalformedMessage)
+		}
+
+		blocks = blocks[1:]
+	}
+
+	if len(blocks) == 0 {
+		return m, nil
+	}
+
+	if err := m.parseFields(blocks[0]); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func isField(line string) bool {
+	for _, f := range fields {
+		if strings.HasPrefix(line, f) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *Message) parseFields(block string) error {
+	lines := strings.Split(block, "\n")
+
+	// fields must appear in specification order, each at most once
+	next := 0
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		pos := -1
+		for j := next; j < len(fields); j++ {
+			if strings.HasPrefix(line, fields[j]) {
+				pos = j
+				break
+			}
+		}
+
+		if pos < 0 {
+			return fmt.Errorf("%w: unexpected line %q", ErrMalformedMessage, line)
+		}
+
+		next = pos + 1
+
+		name := fields[pos]
+		value := strings.TrimPrefix(line, name)
+
+		var err error
+		switch name {
+		case fieldURI:
+			m.URI = value
+		case fieldVersion:
+			m.Version = value
+		case fieldChainID:
+			m.ChainID = value
+		case fieldNonce:
+			m.Nonce = value
+		case fieldIssuedAt:
+			m.IssuedAt, err = time.Parse(time.RFC3339Nano, value)
+		case fieldExpirationTime:
+			m.ExpirationTime, err = time.Parse(time.RFC3339Nano, value)
+		case fieldNotBefore:
+			m.NotBefore, err = time.Parse(time.RFC3339Nano, value)
+		case fieldRequestID:
+			m.RequestID = value
+		case fieldResources:
+			if value != "" {
+				return fmt.Errorf("%w: resources", ErrMalformedMessage)
+			}
+
+			for i+1 < len(lines) && strings.HasPrefix(lines[i+1], "- ") {
+				i++
+				m.Resources = append(m.Resources, strings.TrimPrefix(lines[i], "- "))
+			}
+		}
+
+		if err != nil {
+			return fmt.Errorf("%w: %s%w", ErrMalformedMessage, name, err)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the message fields at the given time.
+func (m *Message) Validate(now time.Time) error {
+	if m.Domain == "" || strings.ContainsAny(m.Domain, " \n") {
+		return fmt.Errorf("%w: domain", ErrMalformedMessage)
+	}
+
+	if strings.Contains(m.Statement, "\n") {
+		return fmt.Errorf("%w: statement", ErrMalformedMessage)
+	}
+
+	if m.Nonce != "" {
+		if len(m.Nonce) < minNonceLength {
+			return ErrInvalidNonce
+		}
+
+		for _, r := range m.Nonce {
+			if r > unicode.MaxASCII || !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				return ErrInvalidNonce
+			}
+		}
+	}
+
+	if !m.ExpirationTime.IsZero() {
+		if !m.IssuedAt.IsZero() && !m.ExpirationTime.After(m.IssuedAt) {
+			return fmt.Errorf("%w: expiration before issued at", ErrMalformedMessage)
+		}
+
+		if !now.Before(m.ExpirationTime) {
+			return ErrExpired
+		}
+	}
+
+	if !m.NotBefore.IsZero() && now.Add(clockSkew).Before(m.NotBefore) {
+		return ErrNotYetValid
+	}
+
+	if !m.IssuedAt.IsZero() && now.Add(clockSkew).Before(m.IssuedAt) {
+		return ErrNotYetValid
+	}
+
+	return nil
+}
+
+// Verify checks the signature over the exact message text against the
+// message's address.
+func Verify(text string, sig solana.Signature) (*Message, error) {
+	m, err := Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ed25519.Verify(m.Address[:], []byte(text), sig[:]) {
+		return nil, ErrInvalidSignature
+	}
+
+	return m, nil
+}
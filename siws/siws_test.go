@@ -0,0 +1,125 @@
+package siws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	issuedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	m := &Message{
+		Domain:         "example.com",
+		Address:        solana.MustPublicKeyFromBase58("9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin"),
+		Statement:      "Sign in to Example",
+		URI:            "https://example.com/login",
+		Version:        "1",
+		ChainID:        "mainnet",
+		Nonce:          "a1b2c3d4e5",
+		IssuedAt:       issuedAt,
+		ExpirationTime: issuedAt.Add(10 * time.Minute),
+		Resources:      []string{"https://example.com/a", "https://example.com/b"},
+	}
+
+	expected := "example.com wants you to sign in with your Solana account:\n" +
+		"9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin\n" +
+		"\n" +
+		"Sign in to Example\n" +
+		"\n" +
+		"URI: https://example.com/login\n" +
+		"Version: 1\n" +
+		"Chain ID: mainnet\n" +
+		"Nonce: a1b2c3d4e5\n" +
+		"Issued At: 2024-01-01T00:00:00Z\n" +
+		"Expiration Time: 2024-01-01T00:10:00Z\n" +
+		"Resources:\n" +
+		"- https://example.com/a\n" +
+		"- https://example.com/b"
+
+	assert.Equal(expected, m.String())
+
+	parsed, err := Parse(expected)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(m, parsed)
+
+	// statement and fields are both optional
+	minimal := &Message{
+		Domain:  "example.com",
+		Address: m.Address,
+	}
+
+	parsed, err = Parse(minimal.String())
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(minimal, parsed)
+
+	_, err = Parse("example.com wants you to sign in with your Solana account:\n" +
+		m.Address.String() + "\n\nNonce: a1b2c3d4e5\nURI: https://example.com")
+	assert.ErrorIs(err, ErrMalformedMessage)
+}
+
+func TestMessageValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	m := &Message{
+		Domain:         "example.com",
+		Nonce:          "a1b2c3d4e5",
+		IssuedAt:       now,
+		ExpirationTime: now.Add(time.Minute),
+	}
+
+	assert.NoError(m.Validate(now))
+	assert.ErrorIs(m.Validate(now.Add(time.Minute)), ErrExpired)
+	assert.ErrorIs(m.Validate(now.Add(-time.Hour)), ErrNotYetValid)
+
+	m.Nonce = "short"
+	assert.ErrorIs(m.Validate(now), ErrInvalidNonce)
+}
+
+func TestVerify(t *testing.T) {
+	assert := assert.New(t)
+
+	key := solana.NewWallet().PrivateKey
+
+	m := &Message{
+		Domain:  "example.com",
+		Address: key.PublicKey(),
+		Nonce:   "a1b2c3d4e5",
+	}
+
+	text := m.String()
+
+	sig, err := key.Sign([]byte(text))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	verified, err := Verify(text, sig)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(m.Address, verified.Address)
+
+	_, err = Verify(text+"\nRequest ID: 1", sig)
+	assert.Error(err)
+
+	_, err = Verify(text, solana.Signature{})
+	assert.ErrorIs(err, ErrInvalidSignature)
+}
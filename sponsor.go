@@ -0,0 +1,264 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+
+	"github.com/flarexio/wallet/account"
+	"github.com/flarexio/wallet/conf"
+	"github.com/flarexio/wallet/inspect"
+)
+
+var (
+	ErrSponsorUnavailable     = errors.New("sponsorship is not configured")
+	ErrSponsorshipDenied      = errors.New("sponsorship denied")
+	ErrSponsorBudgetExhausted = errors.New("sponsorship budget exhausted")
+)
+
+const sponsorWindow = 24 * time.Hour
+
+type sponsor struct {
+	key         solana.PrivateKey
+	programs    []solana.PublicKey
+	maxFee      uint64
+	dailyBudget uint64
+}
+
+func newSponsor(cfg *conf.SponsorConfig) (*sponsor, error) {
+	key, err := solana.PrivateKeyFromSolanaKeygenFile(filepath.Join(cfg.Path, cfg.Account))
+	if err != nil {
+		return nil, err
+	}
+
+	// compute budget instructions only shape the fee, which is capped anyway
+	programs := []solana.PublicKey{solana.ComputeBudget}
+	for _, p := range cfg.Programs {
+		program, err := solana.PublicKeyFromBase58(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sponsored program %q: %w", p, err)
+		}
+
+		programs = append(programs, program)
+	}
+
+	return &sponsor{
+		key:         key,
+		programs:    programs,
+		maxFee:      cfg.MaxFee,
+		dailyBudget: cfg.DailyBudget,
+	}, nil
+}
+
+func (s *sponsor) FeePayer() solana.PublicKey {
+	return s.key.PublicKey()
+}
+
+func (s *sponsor) allowed(program solana.PublicKey) bool {
+	for _, p := range s.programs {
+		if p.Equals(program) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// check applies the sponsorship rules and returns the fee the sponsor would
+// pay. The fee payer must be used for fees only: an instruction that names it
+// could move the sponsor's funds.
+func (s *sponsor) check(tx *solana.Transaction) (uint64, error) {
+	keys := tx.Message.AccountKeys
+	if len(keys) == 0 || !keys[0].Equals(s.FeePayer()) {
+		return 0, fmt.Errorf("%w: fee payer must be %s", ErrSponsorshipDenied, s.FeePayer())
+	}
+
+	for _, inst := range tx.Message.Instructions {
+		// programs are never loaded from lookup tables
+		if int(inst.ProgramIDIndex) >= len(keys) {
+			return 0, fmt.Errorf("%w: invalid program index", ErrSponsorshipDenied)
+		}
+
+		program := keys[inst.ProgramIDIndex]
+
+		if !s.allowed(program) {
+			return 0, fmt.Errorf("%w: program %s is not sponsored", ErrSponsorshipDenied, program)
+		}
+
+		for _, index := range inst.Accounts {
+			if index == 0 {
+				return 0, fmt.Errorf("%w: instruction references the fee payer", ErrSponsorshipDenied)
+			}
+		}
+	}
+
+	summary, err := inspect.Inspect(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	fee := summary.Fee.Total
+	if fee > s.maxFee {
+		return 0, fmt.Errorf("%w: fee %d exceeds %d lamports", ErrSponsorshipDenied, fee, s.maxFee)
+	}
+
+	return fee, nil
+}
+
+type SponsorInfo struct {
+	FeePayer    solana.PublicKey   `json:"fee_payer"`
+	Programs    []solana.PublicKey `json:"programs"`
+	MaxFee      uint64             `json:"max_fee"`
+	DailyBudget uint64             `json:"daily_budget"`
+	Spent       uint64             `json:"spent"`
+	Remaining   uint64             `json:"remaining"`
+}
+
+func (svc *service) Sponsor(ctx context.Context, subject string) (*SponsorInfo, error) {
+	if svc.sponsor == nil {
+		return nil, ErrSponsorUnavailable
+	}
+
+	spent, err := svc.sponsoredFees(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &SponsorInfo{
+		FeePayer:    svc.sponsor.FeePayer(),
+		Programs:    svc.sponsor.programs,
+		MaxFee:      svc.sponsor.maxFee,
+		DailyBudget: svc.sponsor.dailyBudget,
+		Spent:       spent,
+	}
+
+	if spent < info.DailyBudget {
+		info.Remaining = info.DailyBudget - spent
+	}
+
+	return info, nil
+}
+
+// sponsoredFees sums the fees sponsored for subject within the window.
+func (svc *service) sponsoredFees(subject string) (uint64, error) {
+	events, err := svc.accounts.AuditEvents(subject)
+	if err != nil {
+		return 0, err
+	}
+
+	since := time.Now().Add(-sponsorWindow)
+
+	var spent uint64
+	for _, e := range events {
+		if e.Action != account.AuditTransactionSponsored || !e.Time.After(since) {
+			continue
+		}
+
+		fee, err := strconv.ParseUint(e.Details["fee"], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		spent += fee
+	}
+
+	return spent, nil
+}
+
+// checkSponsorship validates a transaction before the passkey ceremony so
+// the user is not asked to approve something the sponsor will refuse. It
+// returns the fee that would be charged to the subject's budget.
+func (svc *service) checkSponsorship(subject string, tx *solana.Transaction) (uint64, error) {
+	if svc.sponsor == nil {
+		return 0, ErrSponsorUnavailable
+	}
+
+	fee, err := svc.sponsor.check(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	spent, err := svc.sponsoredFees(subject)
+	if err != nil {
+		return 0, err
+	}
+
+	if spent+fee > svc.sponsor.dailyBudget {
+		return 0, ErrSponsorBudgetExhausted
+	}
+
+	return fee, nil
+}
+
+// signSponsored signs with the wallet, then co-signs as fee payer and charges
+// the fee to the subject's budget.
+func (svc *service) signSponsored(ctx context.Context, subject string, index int, tx *solana.Transaction) error {
+	svc.accountsLock.Lock()
+	defer svc.accountsLock.Unlock()
+
+	// rules and budget may have changed since initialization
+	fee, err := svc.checkSponsorship(subject, tx)
+	if err != nil {
+		return err
+	}
+
+	a, err := svc.findActive(subject)
+	if err != nil {
+		return err
+	}
+
+	w, err := a.FindWallet(index)
+	if err != nil {
+		return err
+	}
+
+	// the sponsor only pays for transactions the wallet itself authorizes
+	if !tx.IsSigner(w.PublicKey) {
+		return fmt.Errorf("%w: wallet is not a signer", ErrSponsorshipDenied)
+	}
+
+	privkey, err := svc.privateKey(ctx, a, index)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.PartialSign(func(key solana.PublicKey) *solana.PrivateKey {
+		if key.Equals(w.PublicKey) {
+			return &privkey
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	feePayer := svc.sponsor.key
+	if _, err := tx.PartialSign(func(key solana.PublicKey) *solana.PrivateKey {
+		if key.Equals(feePayer.PublicKey()) {
+			return &feePayer
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// every required signer must now be present, the wallet included
+	if err := tx.VerifySignatures(); err != nil {
+		return err
+	}
+
+	e := account.NewAuditEvent(subject, account.AuditTransactionSponsored)
+	e.Details = map[string]string{
+		"wallet":    w.PublicKey.String(),
+		"signature": tx.Signatures[0].String(),
+		"fee":       strconv.FormatUint(fee, 10),
+	}
+
+	return svc.accounts.RecordAudit(e)
+}
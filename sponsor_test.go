@@ -0,0 +1,184 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flarexio/wallet/conf"
+)
+
+func newTestSponsor(t *testing.T, maxFee uint64, budget uint64) *sponsor {
+	t.Helper()
+
+	key := solana.NewWallet().PrivateKey
+
+	keygen := make([]int, len(key))
+	for i, b := range key {
+		keygen[i] = int(b)
+	}
+
+	bs, err := json.Marshal(keygen)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := t.TempDir()
+	if err := os.WriteFile(filepath.Join(path, "sponsor.json"), bs, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := newSponsor(&conf.SponsorConfig{
+		Path:        path,
+		Account:     "sponsor.json",
+		Programs:    []string{solana.MemoProgramID.String()},
+		MaxFee:      maxFee,
+		DailyBudget: budget,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func memoTransaction(t *testing.T, feePayer solana.PublicKey, signer solana.PublicKey) *solana.Transaction {
+	t.Helper()
+
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			solana.NewInstruction(solana.MemoProgramID,
+				solana.AccountMetaSlice{solana.Meta(signer).SIGNER()},
+				[]byte("gasless"),
+			),
+		},
+		solana.Hash{1},
+		solana.TransactionPayer(feePayer),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tx
+}
+
+func TestSponsorCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	s := newTestSponsor(t, 10_000, 1_000_000)
+
+	user := solana.NewWallet().PublicKey()
+
+	fee, err := s.check(memoTransaction(t, s.FeePayer(), user))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	// one signature each for the sponsor and the user
+	assert.Equal(uint64(10_000), fee)
+
+	_, err = s.check(memoTransaction(t, user, user))
+	assert.ErrorIs(err, ErrSponsorshipDenied)
+
+	// a sponsored transaction must not be able to spend the sponsor's funds
+	drain, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(1_000_000, s.FeePayer(), user).Build(),
+		},
+		solana.Hash{1},
+		solana.TransactionPayer(s.FeePayer()),
+	)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	s.programs = append(s.programs, solana.SystemProgramID)
+
+	_, err = s.check(drain)
+	assert.ErrorIs(err, ErrSponsorshipDenied)
+
+	s.programs = s.programs[:len(s.programs)-1]
+
+	transfer, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(1_000, user, solana.NewWallet().PublicKey()).Build(),
+		},
+		solana.Hash{1},
+		solana.TransactionPayer(s.FeePayer()),
+	)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	_, err = s.check(transfer)
+	assert.ErrorIs(err, ErrSponsorshipDenied)
+
+	s.maxFee = 5_000
+	_, err = s.check(memoTransaction(t, s.FeePayer(), user))
+	assert.ErrorIs(err, ErrSponsorshipDenied)
+}
+
+func TestSignSponsored(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	_, err := svc.Sponsor(ctx, "user")
+	assert.ErrorIs(err, ErrSponsorUnavailable)
+
+	svc.sponsor = newTestSponsor(t, 10_000, 15_000)
+
+	from, err := svc.Wallet(ctx, "user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	tx := memoTransaction(t, svc.sponsor.FeePayer(), from)
+
+	if err := svc.signSponsored(ctx, "user", 0, tx); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.NoError(tx.VerifySignatures())
+
+	info, err := svc.Sponsor(ctx, "user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(svc.sponsor.FeePayer(), info.FeePayer)
+	assert.Equal(uint64(10_000), info.Spent)
+	assert.Equal(uint64(5_000), info.Remaining)
+
+	err = svc.signSponsored(ctx, "user", 0, memoTransaction(t, svc.sponsor.FeePayer(), from))
+	assert.ErrorIs(err, ErrSponsorBudgetExhausted)
+
+	// budgets are per subject
+	other, err := svc.Wallet(ctx, "other")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	// the wallet has to be one of the signers
+	stranger := solana.NewWallet().PublicKey()
+	err = svc.signSponsored(ctx, "other", 0, memoTransaction(t, svc.sponsor.FeePayer(), stranger))
+	assert.ErrorIs(err, ErrSponsorshipDenied)
+
+	err = svc.signSponsored(ctx, "other", 0, memoTransaction(t, svc.sponsor.FeePayer(), other))
+	assert.NoError(err)
+}
@@ -0,0 +1,173 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/go-webauthn/webauthn/protocol"
+)
+
+// interval between status polls and rebroadcasts of an unconfirmed transaction
+const submitInterval = 2 * time.Second
+
+type SubmitStatusType string
+
+const (
+	SubmitStatusSent      SubmitStatusType = "sent"
+	SubmitStatusProcessed SubmitStatusType = "processed"
+	SubmitStatusConfirmed SubmitStatusType = "confirmed"
+	SubmitStatusFinalized SubmitStatusType = "finalized"
+	SubmitStatusFailed    SubmitStatusType = "failed"
+	SubmitStatusExpired   SubmitStatusType = "expired"
+)
+
+// Final reports whether no further status will follow.
+func (t SubmitStatusType) Final() bool {
+	switch t {
+	case SubmitStatusFinalized, SubmitStatusFailed, SubmitStatusExpired:
+		return true
+	default:
+		return false
+	}
+}
+
+type SubmitStatus struct {
+	Signature solana.Signature `json:"signature"`
+	Status    SubmitStatusType `json:"status"`
+	Slot      uint64           `json:"slot,omitempty"`
+	Err       string           `json:"error,omitempty"`
+}
+
+func (svc *service) FinalizeSubmitTransaction(ctx context.Context, subject string, req *protocol.ParsedCredentialAssertionData) (*solana.Transaction, bool, <-chan *SubmitStatus, error) {
+	// fail before the passkey challenge is consumed
+	if svc.rpc == nil {
+		return nil, false, nil, ErrSolanaUnavailable
+	}
+
+	tx, versioned, err := svc.FinalizeSignTransaction(ctx, subject, req)
+	if err != nil {
+		return nil, false, nil, err
+	}
+
+	ch, err := svc.SubmitTransaction(ctx, tx)
+	if err != nil {
+		return nil, false, nil, err
+	}
+
+	return tx, versioned, ch, nil
+}
+
+// SubmitTransaction broadcasts a signed transaction and reports its progress
+// until it is finalized, fails, or its blockhash expires. The channel is
+// closed after the final status or when ctx is done.
+func (svc *service) SubmitTransaction(ctx context.Context, transaction *solana.Transaction) (<-chan *SubmitStatus, error) {
+	if svc.rpc == nil {
+		return nil, ErrSolanaUnavailable
+	}
+
+	if len(transaction.Signatures) == 0 {
+		return nil, errors.New("transaction is not signed")
+	}
+
+	ch := make(chan *SubmitStatus)
+
+	go svc.track(ctx, transaction, ch)
+
+	return ch, nil
+}
+
+func (svc *service) track(ctx context.Context, tx *solana.Transaction, ch chan<- *SubmitStatus) {
+	defer close(ch)
+
+	sig := tx.Signatures[0]
+
+	emit := func(status *SubmitStatus) bool {
+		status.Signature = sig
+
+		select {
+		case ch <- status:
+			return !status.Status.Final()
+
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	// the node's own retry queue is disabled; rebroadcasting is done here
+	var maxRetries uint
+
+	// preflight only on the first send so an invalid transaction fails fast
+	if _, err := svc.rpc.SendTransactionWithOpts(ctx, tx, rpc.TransactionOpts{
+		PreflightCommitment: rpc.CommitmentType(svc.solana.Commitment),
+		MaxRetries:          &maxRetries,
+	}); err != nil {
+		emit(&SubmitStatus{Status: SubmitStatusFailed, Err: err.Error()})
+		return
+	}
+
+	if !emit(&SubmitStatus{Status: SubmitStatusSent}) {
+		return
+	}
+
+	ticker := time.NewTicker(submitInterval)
+	defer ticker.Stop()
+
+	var last rpc.ConfirmationStatusType
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+		}
+
+		statuses, err := svc.rpc.GetSignatureStatuses(ctx, false, sig)
+		if err != nil {
+			continue
+		}
+
+		if len(statuses.Value) > 0 && statuses.Value[0] != nil {
+			status := statuses.Value[0]
+
+			if status.Err != nil {
+				reason, err := json.Marshal(status.Err)
+				if err != nil {
+					reason = []byte(fmt.Sprint(status.Err))
+				}
+
+				emit(&SubmitStatus{Status: SubmitStatusFailed, Slot: status.Slot, Err: string(reason)})
+				return
+			}
+
+			if status.ConfirmationStatus == last {
+				continue
+			}
+
+			last = status.ConfirmationStatus
+
+			if !emit(&SubmitStatus{Status: SubmitStatusType(last), Slot: status.Slot}) {
+				return
+			}
+
+			continue
+		}
+
+		// not seen by the cluster (or dropped on a fork): give up once the
+		// blockhash can no longer land, otherwise send it again
+		valid, err := svc.rpc.IsBlockhashValid(ctx, tx.Message.RecentBlockhash, rpc.CommitmentProcessed)
+		if err == nil && !valid.Value {
+			emit(&SubmitStatus{Status: SubmitStatusExpired})
+			return
+		}
+
+		svc.rpc.SendTransactionWithOpts(ctx, tx, rpc.TransactionOpts{
+			SkipPreflight: true,
+			MaxRetries:    &maxRetries,
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package wallet
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubmitTransactionUnavailable(t *testing.T) {
+	assert := assert.New(t)
+
+	svc := newTestService(t)
+
+	_, err := svc.SubmitTransaction(context.Background(), &solana.Transaction{})
+	assert.ErrorIs(err, ErrSolanaUnavailable)
+}
+
+// TestSubmitTransaction runs against a local solana-test-validator, e.g.
+// SOLANA_TEST_VALIDATOR_RPC=http://127.0.0.1:8899
+func TestSubmitTransaction(t *testing.T) {
+	endpoint := os.Getenv("SOLANA_TEST_VALIDATOR_RPC")
+	if endpoint == "" {
+		t.Skip("SOLANA_TEST_VALIDATOR_RPC is not set")
+	}
+
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	svc := newTestService(t)
+	svc.rpc = rpc.New(endpoint)
+	svc.solana.Commitment = "confirmed"
+
+	from, err := svc.Wallet(ctx, "user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if err := airdrop(ctx, svc.rpc, from); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	latest, err := svc.rpc.GetLatestBlockhash(ctx, rpc.CommitmentConfirmed)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(1_000_000, from, solana.NewWallet().PublicKey()).Build(),
+		},
+		latest.Value.Blockhash,
+		solana.TransactionPayer(from),
+	)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if _, err := svc.SignTransaction(ctx, "user", 0, tx); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	ch, err := svc.SubmitTransaction(ctx, tx)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	var last *SubmitStatus
+	for status := range ch {
+		assert.Equal(tx.Signatures[0], status.Signature)
+		last = status
+	}
+
+	if !assert.NotNil(last) {
+		return
+	}
+
+	assert.Equal(SubmitStatusFinalized, last.Status)
+}
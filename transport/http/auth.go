@@ -66,11 +66,25 @@ func JWTAuthorizator(policy policy.Policy) JWTAuth {
 				return
 			}
 
+			c.Set(claimsKey, &claims)
 			c.Next()
 		}
 	}
 }
 
+const claimsKey = "claims"
+
+// ClaimsFromContext returns the claims of a request that passed JWTAuthorizator.
+func ClaimsFromContext(c *gin.Context) (*Claims, bool) {
+	v, ok := c.Get(claimsKey)
+	if !ok {
+		return nil, false
+	}
+
+	claims, ok := v.(*Claims)
+	return claims, ok
+}
+
 func unauthorized(c *gin.Context, code int, err error) {
 	c.Abort()
 	c.Header("WWW-Authenticate", "Bearer realm=wallet")
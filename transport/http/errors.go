@@ -0,0 +1,59 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/flarexio/wallet"
+	"github.com/flarexio/wallet/account"
+	"github.com/flarexio/wallet/offchain"
+)
+
+// errorStatus maps service errors to response codes. Anything unknown keeps
+// the historical 417.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, account.ErrAccountNotFound),
+		errors.Is(err, account.ErrWalletNotFound),
+		errors.Is(err, account.ErrTransactionNotFound):
+		return http.StatusNotFound
+
+	case errors.Is(err, account.ErrAccountDeleted),
+		errors.Is(err, account.ErrTransactionExpired):
+		return http.StatusGone
+
+	case errors.Is(err, account.ErrTransactionMismatch),
+		errors.Is(err, wallet.ErrSponsorshipDenied):
+		return http.StatusForbidden
+
+	case errors.Is(err, account.ErrTransactionReplayed),
+		errors.Is(err, account.ErrWalletExists),
+		errors.Is(err, account.ErrWalletLabelExists):
+		return http.StatusConflict
+
+	case errors.Is(err, account.ErrAccountFrozen):
+		return http.StatusLocked
+
+	case errors.Is(err, wallet.ErrInvalidVerifyRequest),
+		errors.Is(err, wallet.ErrInvalidBuildRequest),
+		errors.Is(err, wallet.ErrInvalidExportRequest):
+		return http.StatusBadRequest
+
+	case errors.Is(err, wallet.ErrSimulationFailed),
+		errors.Is(err, wallet.ErrInvalidSignIn),
+		errors.Is(err, wallet.ErrMessageIsTransaction),
+		errors.Is(err, offchain.ErrInvalidMessage):
+		return http.StatusUnprocessableEntity
+
+	case errors.Is(err, wallet.ErrSolanaUnavailable),
+		errors.Is(err, wallet.ErrSponsorUnavailable):
+		return http.StatusServiceUnavailable
+
+	case errors.Is(err, wallet.ErrExportRateLimited),
+		errors.Is(err, wallet.ErrSponsorBudgetExhausted):
+		return http.StatusTooManyRequests
+
+	default:
+		return http.StatusExpectationFailed
+	}
+}
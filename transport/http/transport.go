@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-kit/kit/endpoint"
@@ -33,7 +34,141 @@ func WalletHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 		if err != nil {
 			c.Abort()
 			c.Error(err)
-			c.String(http.StatusExpectationFailed, err.Error())
+			c.String(errorStatus(err), err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, &resp)
+	}
+}
+
+func ListAccountsHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req *wallet.ListAccountsRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(errorStatus(err), err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, &resp)
+	}
+}
+
+func CreateWalletHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("user")
+		if username == "" {
+			err := errors.New("user is required")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var req *wallet.CreateWalletRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		req.Subject = username
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(errorStatus(err), err.Error())
+			return
+		}
+
+		c.JSON(http.StatusCreated, &resp)
+	}
+}
+
+func ImportWalletHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("user")
+		if username == "" {
+			err := errors.New("user is required")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var req *wallet.ImportWalletRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		req.Subject = username
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(errorStatus(err), err.Error())
+			return
+		}
+
+		c.JSON(http.StatusCreated, &resp)
+	}
+}
+
+func RenameWalletHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("user")
+		if username == "" {
+			err := errors.New("user is required")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		index, err := strconv.Atoi(c.Param("index"))
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var req *wallet.RenameWalletRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		req.Subject = username
+		req.Index = index
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(errorStatus(err), err.Error())
 			return
 		}
 
@@ -52,7 +187,363 @@ func SignMessageHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 			return
 		}
 
-		var req *wallet.SignMessageRequest
+		var req *wallet.SignMessageRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		req.Subject = username
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(errorStatus(err), err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, &resp)
+	}
+}
+
+func InitializeSignMessageHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("user")
+		if username == "" {
+			err := errors.New("user is required")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var req *wallet.InitializeSignMessageRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		req.Subject = username
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(errorStatus(err), err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, &resp)
+	}
+}
+
+func InitializeSignOffchainMessageHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("user")
+		if username == "" {
+			err := errors.New("user is required")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var req *wallet.InitializeSignOffchainMessageRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		req.Subject = username
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(errorStatus(err), err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, &resp)
+	}
+}
+
+func InitializeSignInHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("user")
+		if username == "" {
+			err := errors.New("user is required")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var req *wallet.InitializeSignInRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		req.Subject = username
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(errorStatus(err), err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, &resp)
+	}
+}
+
+func VerifySignInHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("user")
+		if username == "" {
+			err := errors.New("user is required")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var req *wallet.VerifySignInRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		req.Subject = username
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(errorStatus(err), err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, &resp)
+	}
+}
+
+// VerifyHandler serves both the public key and the account variant; the
+// user parameter is optional.
+func VerifyHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req *wallet.VerifyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		req.Subject = c.Param("user")
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(errorStatus(err), err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, &resp)
+	}
+}
+
+func InitializeDeleteAccountHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("user")
+		if username == "" {
+			err := errors.New("user is required")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var req *wallet.InitializeDeleteAccountRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		req.Subject = username
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(errorStatus(err), err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, &resp)
+	}
+}
+
+func FinalizeDeleteAccountHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("user")
+		if username == "" {
+			err := errors.New("user is required")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		assertion, err := protocol.ParseCredentialRequestResponse(c.Request)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		req := &wallet.FinalizeRequest{
+			Subject:   username,
+			Assertion: assertion,
+		}
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(errorStatus(err), err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, &resp)
+	}
+}
+
+func InitializeExportKeyHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("user")
+		if username == "" {
+			err := errors.New("user is required")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var req *wallet.InitializeExportKeyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		req.Subject = username
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(errorStatus(err), err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, &resp)
+	}
+}
+
+// FinalizeExportKeyHandler takes the format from the query string and the
+// keystore passphrase from a header, so that the passphrase stays out of the
+// assertion body and request logs.
+func FinalizeExportKeyHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("user")
+		if username == "" {
+			err := errors.New("user is required")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		passphrase := c.GetHeader("X-Keystore-Passphrase")
+
+		assertion, err := protocol.ParseCredentialRequestResponse(c.Request)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		req := &wallet.FinalizeExportKeyRequest{
+			Subject:    username,
+			Format:     wallet.ExportFormat(c.Query("format")),
+			Assertion:  assertion,
+			Passphrase: []byte(passphrase),
+		}
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(errorStatus(err), err.Error())
+			return
+		}
+
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, &resp)
+	}
+}
+
+func FreezeAccountHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("user")
+		if username == "" {
+			err := errors.New("user is required")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		claims, ok := ClaimsFromContext(c)
+		if !ok {
+			err := errors.New("claims not found")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		var req *wallet.FreezeAccountRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.Abort()
 			c.Error(err)
@@ -61,13 +552,14 @@ func SignMessageHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 		}
 
 		req.Subject = username
+		req.By = claims.Subject
 
 		ctx := c.Request.Context()
 		resp, err := endpoint(ctx, req)
 		if err != nil {
 			c.Abort()
 			c.Error(err)
-			c.String(http.StatusExpectationFailed, err.Error())
+			c.String(errorStatus(err), err.Error())
 			return
 		}
 
@@ -75,7 +567,7 @@ func SignMessageHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 	}
 }
 
-func InitializeSignMessageHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+func InitializeUnfreezeAccountHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		username := c.Param("user")
 		if username == "" {
@@ -86,7 +578,16 @@ func InitializeSignMessageHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 			return
 		}
 
-		var req *wallet.InitializeSignMessageRequest
+		claims, ok := ClaimsFromContext(c)
+		if !ok {
+			err := errors.New("claims not found")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		var req *wallet.InitializeUnfreezeAccountRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.Abort()
 			c.Error(err)
@@ -95,13 +596,61 @@ func InitializeSignMessageHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 		}
 
 		req.Subject = username
+		req.By = claims.Subject
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(errorStatus(err), err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, &resp)
+	}
+}
+
+func FinalizeUnfreezeAccountHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("user")
+		if username == "" {
+			err := errors.New("user is required")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		claims, ok := ClaimsFromContext(c)
+		if !ok {
+			err := errors.New("claims not found")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		assertion, err := protocol.ParseCredentialRequestResponse(c.Request)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		req := &wallet.FinalizeUnfreezeAccountRequest{
+			Subject:   username,
+			By:        claims.Subject,
+			Assertion: assertion,
+		}
 
 		ctx := c.Request.Context()
 		resp, err := endpoint(ctx, req)
 		if err != nil {
 			c.Abort()
 			c.Error(err)
-			c.String(http.StatusExpectationFailed, err.Error())
+			c.String(errorStatus(err), err.Error())
 			return
 		}
 
@@ -111,7 +660,16 @@ func InitializeSignMessageHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 
 func FinalizeSignMessageHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		req, err := protocol.ParseCredentialRequestResponse(c.Request)
+		username := c.Param("user")
+		if username == "" {
+			err := errors.New("user is required")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		assertion, err := protocol.ParseCredentialRequestResponse(c.Request)
 		if err != nil {
 			c.Abort()
 			c.Error(err)
@@ -119,12 +677,17 @@ func FinalizeSignMessageHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 			return
 		}
 
+		req := &wallet.FinalizeRequest{
+			Subject:   username,
+			Assertion: assertion,
+		}
+
 		ctx := c.Request.Context()
 		resp, err := endpoint(ctx, req)
 		if err != nil {
 			c.Abort()
 			c.Error(err)
-			c.String(http.StatusExpectationFailed, err.Error())
+			c.String(errorStatus(err), err.Error())
 			return
 		}
 
@@ -158,7 +721,7 @@ func SignTransactionHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 		if err != nil {
 			c.Abort()
 			c.Error(err)
-			c.String(http.StatusExpectationFailed, err.Error())
+			c.String(errorStatus(err), err.Error())
 			return
 		}
 
@@ -192,7 +755,41 @@ func InitializeSignTransactionHandler(endpoint endpoint.Endpoint) gin.HandlerFun
 		if err != nil {
 			c.Abort()
 			c.Error(err)
-			c.String(http.StatusExpectationFailed, err.Error())
+			c.String(errorStatus(err), err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, &resp)
+	}
+}
+
+func InitializeSignTransactionsHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("user")
+		if username == "" {
+			err := errors.New("user is required")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var req *wallet.InitializeSignTransactionsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		req.Subject = username
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(errorStatus(err), err.Error())
 			return
 		}
 
@@ -202,20 +799,205 @@ func InitializeSignTransactionHandler(endpoint endpoint.Endpoint) gin.HandlerFun
 
 func FinalizeSignTransactionHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		req, err := protocol.ParseCredentialRequestResponse(c.Request)
+		username := c.Param("user")
+		if username == "" {
+			err := errors.New("user is required")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		assertion, err := protocol.ParseCredentialRequestResponse(c.Request)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		req := &wallet.FinalizeRequest{
+			Subject:   username,
+			Assertion: assertion,
+		}
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(errorStatus(err), err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, &resp)
+	}
+}
+
+func FinalizeSubmitTransactionHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("user")
+		if username == "" {
+			err := errors.New("user is required")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		assertion, err := protocol.ParseCredentialRequestResponse(c.Request)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		req := &wallet.FinalizeRequest{
+			Subject:   username,
+			Assertion: assertion,
+		}
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(errorStatus(err), err.Error())
+			return
+		}
+
+		result, ok := resp.(*wallet.FinalizeSubmitTransactionResponse)
+		if !ok {
+			err := errors.New("invalid type")
+			c.Abort()
+			c.Error(err)
+			c.String(errorStatus(err), err.Error())
+			return
+		}
+
+		var txSent bool
+		c.Stream(func(w io.Writer) bool {
+			if !txSent {
+				c.SSEvent("transaction", result.FinalizeSignTransactionResponse)
+				txSent = true
+				return true
+			}
+
+			select {
+			case <-ctx.Done():
+				return false
+
+			case status, ok := <-result.Status:
+				if !ok {
+					c.SSEvent("fail", "tracking stopped")
+					return false
+				}
+
+				c.SSEvent("status", status)
+				return !status.Status.Final()
+			}
+		})
+	}
+}
+
+// PortfolioHandler takes the wallet index from the query string and
+// defaults to the primary wallet.
+func PortfolioHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("user")
+		if username == "" {
+			err := errors.New("user is required")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var req *wallet.PortfolioRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		req.Subject = username
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(errorStatus(err), err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, &resp)
+	}
+}
+
+func BuildTransferHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("user")
+		if username == "" {
+			err := errors.New("user is required")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var req *wallet.BuildTransferRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		req.Subject = username
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, req)
 		if err != nil {
+			c.Abort()
+			c.Error(err)
+			c.String(errorStatus(err), err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, &resp)
+	}
+}
+
+func BuildMemoHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("user")
+		if username == "" {
+			err := errors.New("user is required")
+			c.Abort()
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var req *wallet.BuildMemoRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
 			c.Abort()
 			c.Error(err)
 			c.String(http.StatusBadRequest, err.Error())
 			return
 		}
 
+		req.Subject = username
+
 		ctx := c.Request.Context()
 		resp, err := endpoint(ctx, req)
 		if err != nil {
 			c.Abort()
 			c.Error(err)
-			c.String(http.StatusExpectationFailed, err.Error())
+			c.String(errorStatus(err), err.Error())
 			return
 		}
 
@@ -238,7 +1020,7 @@ func CreateSessionHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 		if err != nil {
 			c.Abort()
 			c.Error(err)
-			c.String(http.StatusExpectationFailed, err.Error())
+			c.String(errorStatus(err), err.Error())
 			return
 		}
 
@@ -247,7 +1029,7 @@ func CreateSessionHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 			err := errors.New("invalid type")
 			c.Abort()
 			c.Error(err)
-			c.String(http.StatusExpectationFailed, err.Error())
+			c.String(errorStatus(err), err.Error())
 			return
 		}
 
@@ -302,7 +1084,7 @@ func SessionDataHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 		if err != nil {
 			c.Abort()
 			c.Error(err)
-			c.String(http.StatusExpectationFailed, err.Error())
+			c.String(errorStatus(err), err.Error())
 			return
 		}
 
@@ -336,7 +1118,7 @@ func AckSessionHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 		if err != nil {
 			c.Abort()
 			c.Error(err)
-			c.String(http.StatusExpectationFailed, err.Error())
+			c.String(errorStatus(err), err.Error())
 			return
 		}
 
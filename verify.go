@@ -0,0 +1,188 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+
+	"github.com/flarexio/wallet/offchain"
+)
+
+var ErrInvalidVerifyRequest = errors.New("invalid verify request")
+
+type VerifyKind string
+
+const (
+	VerifyKindMessage     VerifyKind = "message"
+	VerifyKindOffchain    VerifyKind = "offchain"
+	VerifyKindTransaction VerifyKind = "transaction"
+)
+
+type SignerResult struct {
+	PublicKey solana.PublicKey `json:"public_key"`
+	Signature solana.Signature `json:"signature"`
+	Valid     bool             `json:"valid"`
+}
+
+// VerifyResult reports every required signer. Valid refers to the verified
+// address alone; Complete requires all signers to be valid.
+type VerifyResult struct {
+	Kind     VerifyKind        `json:"kind"`
+	Address  solana.PublicKey  `json:"address"`
+	Valid    bool              `json:"valid"`
+	Complete bool              `json:"complete"`
+	Signers  []*SignerResult   `json:"signers"`
+	Envelope *offchain.Message `json:"envelope,omitempty"`
+}
+
+// Verify checks signatures over a message, an off-chain envelope or a
+// serialized transaction. The address is the explicit public key if given,
+// otherwise the primary wallet of the subject's account.
+func (svc *service) Verify(ctx context.Context, req *VerifyRequest) (*VerifyResult, error) {
+	if (len(req.Message) == 0) == (len(req.Transaction) == 0) {
+		return nil, fmt.Errorf("%w: exactly one of message or transaction is required", ErrInvalidVerifyRequest)
+	}
+
+	address, err := svc.verifyAddress(req)
+	if err != nil {
+		return nil, err
+	}
+
+	sigs := req.Signatures
+	if req.Signature != nil {
+		sigs = append([]solana.Signature{*req.Signature}, sigs...)
+	}
+
+	var result *VerifyResult
+	if len(req.Message) > 0 {
+		result, err = verifyMessage(address, req.Message, sigs)
+	} else {
+		result, err = verifyTransaction(req.Transaction, sigs)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	result.Address = address
+	result.Complete = len(result.Signers) > 0
+
+	for _, signer := range result.Signers {
+		if signer.PublicKey.Equals(address) && signer.Valid {
+			result.Valid = true
+		}
+
+		if !signer.Valid {
+			result.Complete = false
+		}
+	}
+
+	return result, nil
+}
+
+// verifyAddress resolves the address to verify for. Unlike Service.Wallet it
+// never creates an account, since verification is public.
+func (svc *service) verifyAddress(req *VerifyRequest) (solana.PublicKey, error) {
+	if req.PublicKey != nil {
+		return *req.PublicKey, nil
+	}
+
+	if req.Subject == "" {
+		return solana.PublicKey{}, fmt.Errorf("%w: public key is required", ErrInvalidVerifyRequest)
+	}
+
+	a, err := svc.accounts.Find(req.Subject)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+
+	return a.Wallet(), nil
+}
+
+func verifyMessage(address solana.PublicKey, message []byte, sigs []solana.Signature) (*VerifyResult, error) {
+	result := &VerifyResult{
+		Kind:    VerifyKindMessage,
+		Signers: []*SignerResult{{PublicKey: address}},
+	}
+
+	// envelope signatures are given in signer order
+	if offchain.IsEnvelope(message) {
+		m, err := offchain.Parse(message)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Kind = VerifyKindOffchain
+		result.Envelope = m
+		result.Signers = make([]*SignerResult, len(m.Signers))
+
+		for i, signer := range m.Signers {
+			result.Signers[i] = &SignerResult{PublicKey: signer}
+		}
+	}
+
+	if len(sigs) != len(result.Signers) {
+		return nil, fmt.Errorf("%w: expected %d signatures", ErrInvalidVerifyRequest, len(result.Signers))
+	}
+
+	for i, signer := range result.Signers {
+		signer.Signature = sigs[i]
+		signer.Valid = ed25519.Verify(signer.PublicKey[:], message, sigs[i][:])
+	}
+
+	return result, nil
+}
+
+func verifyTransaction(raw []byte, sigs []solana.Signature) (*VerifyResult, error) {
+	tx, err := solana.TransactionFromBytes(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidVerifyRequest, err)
+	}
+
+	// verify over the exact message bytes rather than a re-encoding
+	decoder := bin.NewBinDecoder(raw)
+	count, err := decoder.ReadCompactU16()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidVerifyRequest, err)
+	}
+
+	offset := len(raw) - decoder.Remaining() + 64*count
+	if offset > len(raw) {
+		return nil, fmt.Errorf("%w: truncated transaction", ErrInvalidVerifyRequest)
+	}
+
+	message := raw[offset:]
+
+	required := int(tx.Message.Header.NumRequiredSignatures)
+	if required > len(tx.Message.AccountKeys) {
+		return nil, fmt.Errorf("%w: invalid header", ErrInvalidVerifyRequest)
+	}
+
+	// detached signatures replace the ones embedded in the transaction
+	if len(sigs) == 0 {
+		sigs = tx.Signatures
+	}
+
+	if len(sigs) != required {
+		return nil, fmt.Errorf("%w: expected %d signatures", ErrInvalidVerifyRequest, required)
+	}
+
+	result := &VerifyResult{
+		Kind:    VerifyKindTransaction,
+		Signers: make([]*SignerResult, required),
+	}
+
+	for i, key := range tx.Message.AccountKeys[:required] {
+		result.Signers[i] = &SignerResult{
+			PublicKey: key,
+			Signature: sigs[i],
+			Valid:     !sigs[i].IsZero() && ed25519.Verify(key[:], message, sigs[i][:]),
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,170 @@
+package wallet
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flarexio/wallet/account"
+	"github.com/flarexio/wallet/offchain"
+)
+
+func TestVerifyMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	address, err := svc.Wallet(ctx, "user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	message := []byte("hello")
+
+	sig, err := svc.SignMessage(ctx, "user", 0, message)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	result, err := svc.Verify(ctx, &VerifyRequest{
+		Subject:   "user",
+		Message:   message,
+		Signature: &sig,
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(VerifyKindMessage, result.Kind)
+	assert.Equal(address, result.Address)
+	assert.True(result.Valid)
+	assert.True(result.Complete)
+
+	other := solana.NewWallet().PublicKey()
+
+	result, err = svc.Verify(ctx, &VerifyRequest{
+		PublicKey: &other,
+		Message:   message,
+		Signature: &sig,
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.False(result.Valid)
+
+	// public verification must not create accounts
+	_, err = svc.Verify(ctx, &VerifyRequest{
+		Subject:   "nobody",
+		Message:   message,
+		Signature: &sig,
+	})
+	assert.ErrorIs(err, account.ErrAccountNotFound)
+
+	envelope, err := offchain.New([32]byte{}, []solana.PublicKey{address, other}, message)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	bs, err := envelope.MarshalBinary()
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	sig, err = svc.SignMessage(ctx, "user", 0, bs)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	result, err = svc.Verify(ctx, &VerifyRequest{
+		Subject:    "user",
+		Message:    bs,
+		Signatures: []solana.Signature{sig, {}},
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(VerifyKindOffchain, result.Kind)
+	assert.True(result.Valid)
+	assert.False(result.Complete)
+	assert.Len(result.Signers, 2)
+}
+
+func TestVerifyTransaction(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	from, err := svc.Wallet(ctx, "user")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(1_000, from, solana.NewWallet().PublicKey()).Build(),
+		},
+		solana.Hash{1},
+		solana.TransactionPayer(from),
+	)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if _, err := svc.SignTransaction(ctx, "user", 0, tx); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	result, err := svc.Verify(ctx, &VerifyRequest{
+		Subject:     "user",
+		Transaction: raw,
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(VerifyKindTransaction, result.Kind)
+	assert.True(result.Valid)
+	assert.True(result.Complete)
+
+	result, err = svc.Verify(ctx, &VerifyRequest{
+		Subject:     "user",
+		Transaction: raw,
+		Signatures:  []solana.Signature{{}},
+	})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.False(result.Valid)
+
+	_, err = svc.Verify(ctx, &VerifyRequest{Subject: "user"})
+	assert.ErrorIs(err, ErrInvalidVerifyRequest)
+}